@@ -2,11 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 
 	meerkatv1alpha1 "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 	"github.com/borchero/meerkat-operator/pkg/controllers"
 	"github.com/borchero/meerkat-operator/pkg/crypto"
-	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/borchero/meerkat-operator/pkg/webhooks"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,7 +19,9 @@ import (
 
 type environment struct {
 	Debug                bool
-	EnableLeaderElection bool `split_words:"true"`
+	EnableLeaderElection bool   `split_words:"true"`
+	EnableWebhooks       bool   `split_words:"true"`
+	ServiceAccount       string `split_words:"true"`
 	Server               controllers.Config
 	Vault                crypto.VaultConfig
 }
@@ -45,21 +48,10 @@ func main() {
 	utilruntime.Must(meerkatv1alpha1.AddToScheme(scheme))
 
 	// Configure Vault
-	config := vaultapi.DefaultConfig()
-	config.Address = env.Vault.Addr
-	if err := config.ConfigureTLS(&vaultapi.TLSConfig{
-		CACert:        env.Vault.CaCrt,
-		TLSServerName: env.Vault.ServerName,
-	}); err != nil {
-		panic(err)
-	}
-	vault, err := vaultapi.NewClient(config)
+	vault, err := crypto.NewVaultClient(context.Background(), env.Vault, logger.Named("vault"))
 	if err != nil {
 		panic(err)
 	}
-	go crypto.EnsureTokenUpdated(
-		context.Background(), vault, env.Vault.TokenMount, logger.Named("vault"),
-	)
 
 	// Setup manager
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -73,8 +65,23 @@ func main() {
 	}
 
 	// Setup reconcilers
-	controllers.MustSetupOvpnServerReconciler(env.Server, vault, mgr, logger.Named("ovpn-server"))
-	controllers.MustSetupOvpnClientReconciler(env.Server, vault, mgr, logger.Named("ovpn-client"))
+	vaults := controllers.NewVaultProvider(env.Server, vault, mgr, logger.Named("vault"))
+	controllers.MustSetupOvpnPKIReconciler(vaults, mgr, logger.Named("ovpn-pki"))
+	controllers.MustSetupOvpnServerReconciler(env.Server, vaults, mgr, logger.Named("ovpn-server"))
+	controllers.MustSetupOvpnClientReconciler(env.Server, vaults, mgr, logger.Named("ovpn-client"))
+	controllers.MustSetupOvpnConnectorReconciler(env.Server, mgr, logger.Named("ovpn-connector"))
+
+	// Setup webhooks
+	if env.EnableWebhooks {
+		operator := ""
+		if env.ServiceAccount != "" {
+			operator = fmt.Sprintf(
+				"system:serviceaccount:%s:%s", env.Server.Namespace, env.ServiceAccount,
+			)
+		}
+		webhooks.SetupRequesterWebhook(mgr, operator, logger.Named("requester-webhook"))
+		webhooks.SetupInjectorWebhook(mgr, env.Server.Image, logger.Named("injector-webhook"))
+	}
 
 	// And run
 	logger.Info("starting manager")
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/borchero/meerkat-operator/pkg/supervisor"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Setup
+	configPath := flag.String("config", "/app/supervisor.json", "path to the supervisor config")
+	mode := flag.String("mode", "server", "either `server` or `connector`")
+	profile := flag.String("profile", "", "path to the profile of a connector, replaces -config")
+	healthPort := flag.Int("health-port", 8080, "port of the health endpoints if -profile is set")
+	debug := flag.Bool("debug", false, "enable debug logs")
+	flag.Parse()
+
+	config := zap.NewProductionConfig()
+	if *debug {
+		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+	}
+	logger, err := config.Build(zap.AddStacktrace(zap.FatalLevel))
+	if err != nil {
+		panic(err)
+	}
+
+	// Stop gracefully on termination
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	// And run
+	if *mode == "connector" {
+		cfg := supervisor.ConnectorConfig{
+			Profile:        *profile,
+			ManagementPort: supervisor.DefaultManagementPort,
+			HealthPort:     *healthPort,
+		}
+		if *profile == "" {
+			cfg, err = supervisor.LoadConnectorConfig(*configPath)
+			if err != nil {
+				logger.Fatal("failed to load config", zap.Error(err))
+			}
+		}
+		connector := supervisor.NewConnector(cfg, logger.Named("connector"))
+		if err := connector.Run(ctx); err != nil {
+			logger.Fatal("failed to run connector", zap.Error(err))
+		}
+		return
+	}
+	cfg, err := supervisor.LoadConfig(*configPath)
+	if err != nil {
+		logger.Fatal("failed to load config", zap.Error(err))
+	}
+	if err := supervisor.New(cfg, logger.Named("supervisor")).Run(ctx); err != nil {
+		logger.Fatal("failed to run supervisor", zap.Error(err))
+	}
+}
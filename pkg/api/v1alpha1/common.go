@@ -30,3 +30,20 @@ type OvpnServerCertificateConfig struct {
 type OvpnClientCertificateConfig struct {
 	OvpnCertificateConfig `json:",inline"`
 }
+
+//-------------------------------------------------------------------------------------------------
+
+const (
+	// AnnotationKeyReissue is the annotation on an OvpnClient whose change requests the client's
+	// certificate to be revoked and reissued.
+	AnnotationKeyReissue = "meerkat.borchero.com/reissue"
+	// AnnotationKeyRequestedBy is the annotation on an OvpnServer or OvpnClient which records the
+	// user who requested the issuance of its certificate. It is set by the operator's webhook.
+	AnnotationKeyRequestedBy = "meerkat.borchero.com/requested-by"
+	// AnnotationKeyInject is the annotation on a pod which requests a VPN sidecar to be injected.
+	// The sidecar connects with the profile of the OvpnClient with the given name.
+	AnnotationKeyInject = "meerkat.borchero.com/inject"
+	// AnnotationKeyInjectServer is the annotation on a pod requesting a VPN sidecar which names
+	// the OvpnServer to create the pod's OvpnClient for if it does not exist yet.
+	AnnotationKeyInjectServer = "meerkat.borchero.com/inject-server"
+)
@@ -0,0 +1,82 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&OvpnCertificate{}, &OvpnCertificateList{})
+}
+
+// OvpnCertificate records a certificate that has been issued by the PKI of an OVPN server. It is
+// managed by the operator and serves as an audit trail of who had access to a server when.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Server",type=string,JSONPath=`.spec.serverName`
+// +kubebuilder:printcolumn:name="Usage",type=string,JSONPath=`.spec.usage`
+// +kubebuilder:printcolumn:name="Common Name",type=string,JSONPath=`.spec.commonName`
+// +kubebuilder:printcolumn:name="Serial",type=string,JSONPath=`.spec.serial`,priority=1
+// +kubebuilder:printcolumn:name="Requested By",type=string,JSONPath=`.spec.requestedBy`
+// +kubebuilder:printcolumn:name="Not Before",type=date,JSONPath=`.spec.notBefore`
+// +kubebuilder:printcolumn:name="Not After",type=date,JSONPath=`.spec.notAfter`
+// +kubebuilder:printcolumn:name="Revoked",type=date,JSONPath=`.status.revokedAt`
+type OvpnCertificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OvpnCertificateSpec   `json:"spec"`
+	Status OvpnCertificateStatus `json:"status,omitempty"`
+}
+
+// OvpnCertificateList defines the schema for a list of OVPN certificates.
+// +kubebuilder:object:root=true
+type OvpnCertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OvpnCertificate `json:"items"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnCertificateUsage defines what a certificate is used for.
+// +kubebuilder:validation:Enum=Server;Client
+type OvpnCertificateUsage string
+
+const (
+	// OvpnCertificateUsageServer is used for certificates identifying an OVPN server.
+	OvpnCertificateUsageServer OvpnCertificateUsage = "Server"
+	// OvpnCertificateUsageClient is used for certificates identifying an OVPN client.
+	OvpnCertificateUsageClient OvpnCertificateUsage = "Client"
+)
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnCertificateSpec describes an issued certificate.
+type OvpnCertificateSpec struct {
+	// The name of the OvpnServer whose PKI issued the certificate.
+	ServerName string `json:"serverName"`
+	// The name of the OvpnClient that the certificate was issued for. Empty for server
+	// certificates.
+	ClientName string `json:"clientName,omitempty"`
+	// What the certificate is used for.
+	Usage OvpnCertificateUsage `json:"usage"`
+	// The common name of the certificate.
+	CommonName string `json:"commonName"`
+	// The serial number of the certificate.
+	Serial string `json:"serial"`
+	// The time from which on the certificate is valid.
+	NotBefore metav1.Time `json:"notBefore"`
+	// The time at which the certificate expires.
+	NotAfter metav1.Time `json:"notAfter"`
+	// The user who requested the certificate, if known.
+	RequestedBy string `json:"requestedBy,omitempty"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnCertificateStatus describes the status of an issued certificate.
+type OvpnCertificateStatus struct {
+	// The time at which the certificate was revoked, if it has been revoked.
+	RevokedAt *metav1.Time `json:"revokedAt,omitempty"`
+}
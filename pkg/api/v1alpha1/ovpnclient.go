@@ -30,15 +30,68 @@ type OvpnClientList struct {
 
 //-------------------------------------------------------------------------------------------------
 
+// OvpnProfileMode defines how client profiles are provided for multiple servers.
+type OvpnProfileMode string
+
+const (
+	// OvpnProfileModeCombined provides a single profile for all servers.
+	OvpnProfileModeCombined OvpnProfileMode = "Combined"
+	// OvpnProfileModePerServer provides a dedicated profile for each server.
+	OvpnProfileModePerServer OvpnProfileMode = "PerServer"
+)
+
+//-------------------------------------------------------------------------------------------------
+
 // OvpnClientSpec describes an OVPN client.
 type OvpnClientSpec struct {
 	// The name of the OvpnServer the client is associated with. The server must be in the same
 	// namespace as the client.
-	ServerName string `json:"serverName"`
+	ServerName string `json:"serverName,omitempty"`
+	// The names of additional OvpnServers the client is associated with. All servers must reside
+	// in the client's namespace and reference the same OvpnPKI.
+	ServerNames []string `json:"serverNames,omitempty"`
+	// How the profiles for multiple servers are provided. `Combined` creates a single profile
+	// with one remote per server, using the security settings of the first server. `PerServer`
+	// creates one profile per server, stored at `<servername>.ovpn`.
+	// +kubebuilder:default=Combined
+	// +kubebuilder:validation:Enum=Combined;PerServer
+	ProfileMode OvpnProfileMode `json:"profileMode,omitempty"`
 	// The common name of the user. Typically a unique identifier such as the email address.
 	CommonName string `json:"commonName"`
 	// The certificate configuration.
 	Certificate OvpnClientCertificate `json:"certificate,omitempty"`
+	// The point in time at which the client's access ends. Once reached, the client's certificate
+	// is revoked and its profile is removed.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// The duration, starting at the creation of the client, for which the client has access. If
+	// `expiresAt` is set as well, the earlier deadline applies.
+	AccessWindow metav1.Duration `json:"accessWindow,omitempty"`
+	// Whether the client should be deleted once its access has expired. Otherwise, the client is
+	// kept without a certificate.
+	// +kubebuilder:default=false
+	DeleteOnExpiry bool `json:"deleteOnExpiry,omitempty"`
+	// Whether the client is suspended. Suspended clients cannot connect to the server and are
+	// disconnected if connected. In contrast to deleting the client, its certificate and profile
+	// are kept such that access can be restored.
+	// +kubebuilder:default=false
+	Suspended bool `json:"suspended,omitempty"`
+	// The networks that are reachable through the client if it acts as a gateway, e.g. for a
+	// router connecting a branch office.
+	// Gateways are ignored by servers running multiple replicas as only the replica that the
+	// client is connected to could route traffic to the networks.
+	Gateway *OvpnClientGateway `json:"gateway,omitempty"`
+}
+
+// OvpnClientGateway describes the networks behind a client acting as a gateway.
+type OvpnClientGateway struct {
+	// The networks behind the client. Traffic from the VPN to these networks is routed to the
+	// client. IPv6 networks are ignored by servers without IPv6.
+	// +kubebuilder:validation:MinItems=1
+	Subnets []SubnetMask `json:"subnets"`
+	// Whether the networks are pushed as routes to all other clients of the servers such that
+	// they can reach the networks as well.
+	// +kubebuilder:default=false
+	Advertise bool `json:"advertise,omitempty"`
 }
 
 // OvpnClientCertificate describe the configuration of a OVPN client certificate.
@@ -53,4 +106,23 @@ type OvpnClientCertificate struct {
 
 // OvpnClientStatus describes the status of an OVPN client.
 type OvpnClientStatus struct {
+	// The value of the `meerkat.borchero.com/reissue` annotation that the current certificate was
+	// issued for.
+	ObservedReissue string `json:"observedReissue,omitempty"`
+	// The time at which the client's certificate was last rotated.
+	LastRotation *metav1.Time `json:"lastRotation,omitempty"`
+	// The certificates that have been issued for the client and have not expired yet.
+	Certificates []OvpnIssuedCertificate `json:"certificates,omitempty"`
+}
+
+// OvpnIssuedCertificate describes a certificate that has been issued for a client.
+type OvpnIssuedCertificate struct {
+	// The serial number of the certificate.
+	Serial string `json:"serial"`
+	// The time at which the certificate was issued.
+	IssuedAt metav1.Time `json:"issuedAt"`
+	// The time at which the certificate expires.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+	// The time at which the certificate was revoked, if it has been revoked.
+	RevokedAt *metav1.Time `json:"revokedAt,omitempty"`
 }
@@ -1,6 +1,11 @@
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // ObjectRefCertificateSecret returns a reference to the secret containing the OVPN certificate.
 func (c *OvpnClient) ObjectRefCertificateSecret() metav1.ObjectMeta {
@@ -13,3 +18,84 @@ func (c *OvpnClient) ObjectRefCertificateSecret() metav1.ObjectMeta {
 	}
 	return ref
 }
+
+// AllServerNames returns the names of all servers that the client is associated with, starting
+// with `serverName`.
+func (c *OvpnClient) AllServerNames() []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, name := range append([]string{c.Spec.ServerName}, c.Spec.ServerNames...) {
+		if name != "" && !seen[name] {
+			result = append(result, name)
+			seen[name] = true
+		}
+	}
+	return result
+}
+
+// IsAssociatedWith returns whether the client is associated with the server with the given name.
+func (c *OvpnClient) IsAssociatedWith(serverName string) bool {
+	for _, name := range c.AllServerNames() {
+		if name == serverName {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileKey returns the key of the profile within the client's certificate secret that is used
+// to connect to the client's servers, i.e. the combined profile or the profile of its first
+// server.
+func (c *OvpnClient) ProfileKey() string {
+	servers := c.AllServerNames()
+	if c.Spec.DefaultedProfileMode() == OvpnProfileModePerServer && len(servers) > 0 {
+		return fmt.Sprintf("%s.ovpn", servers[0])
+	}
+	return "certificate.ovpn"
+}
+
+// DefaultedProfileMode returns the provided profile mode or `Combined`.
+func (s OvpnClientSpec) DefaultedProfileMode() OvpnProfileMode {
+	if s.ProfileMode == "" {
+		return OvpnProfileModeCombined
+	}
+	return s.ProfileMode
+}
+
+// AccessDeadline returns the point in time at which the client's access ends. The boolean flag
+// indicates whether the client's access is limited at all.
+func (c *OvpnClient) AccessDeadline() (time.Time, bool) {
+	var deadline time.Time
+	if c.Spec.AccessWindow.Duration > 0 {
+		deadline = c.CreationTimestamp.Add(c.Spec.AccessWindow.Duration)
+	}
+	if c.Spec.ExpiresAt != nil {
+		if deadline.IsZero() || c.Spec.ExpiresAt.Time.Before(deadline) {
+			deadline = c.Spec.ExpiresAt.Time
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+// HasIssuedCertificate returns whether the certificate with the given serial is tracked in the
+// client's status.
+func (c *OvpnClient) HasIssuedCertificate(serial string) bool {
+	for _, certificate := range c.Status.Certificates {
+		if certificate.Serial == serial {
+			return true
+		}
+	}
+	return false
+}
+
+// UnexpiredCertificates returns all certificates tracked in the client's status which have not
+// expired yet.
+func (c *OvpnClient) UnexpiredCertificates() []OvpnIssuedCertificate {
+	result := []OvpnIssuedCertificate{}
+	for _, certificate := range c.Status.Certificates {
+		if certificate.ExpiresAt.After(time.Now()) {
+			result = append(result, certificate)
+		}
+	}
+	return result
+}
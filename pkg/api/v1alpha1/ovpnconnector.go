@@ -0,0 +1,122 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&OvpnConnector{}, &OvpnConnectorList{})
+}
+
+// OvpnConnector defines the schema for an OVPN connector, i.e. an OpenVPN client running within
+// the cluster which forwards TCP ports to hosts in remote networks.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Tunnel IP",type=string,JSONPath=`.status.tunnelIP`
+type OvpnConnector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OvpnConnectorSpec   `json:"spec"`
+	Status OvpnConnectorStatus `json:"status,omitempty"`
+}
+
+// OvpnConnectorList defines the schema for a list of OVPN connectors.
+// +kubebuilder:object:root=true
+type OvpnConnectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OvpnConnector `json:"items"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnConnectorState defines the state of the connection of a connector.
+type OvpnConnectorState string
+
+const (
+	// OvpnConnectorStatePending describes a connector without a running pod.
+	OvpnConnectorStatePending OvpnConnectorState = "Pending"
+	// OvpnConnectorStateConnecting describes a connector whose tunnel is not established yet.
+	OvpnConnectorStateConnecting OvpnConnectorState = "Connecting"
+	// OvpnConnectorStateConnected describes a connector whose tunnel is established.
+	OvpnConnectorStateConnected OvpnConnectorState = "Connected"
+)
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnConnectorSpec describes an OVPN connector.
+type OvpnConnectorSpec struct {
+	// The profile that the connector connects with.
+	Profile OvpnConnectorProfile `json:"profile"`
+	// TCP ports of hosts in the remote networks which are exposed within the cluster by a service
+	// named after the connector. Pods reach the hosts through the tunnel by connecting to the
+	// service. Other traffic of the cluster's pods is not routed through the tunnel.
+	Forwards []OvpnConnectorForward `json:"forwards,omitempty"`
+	// The deployment configuration.
+	Deployment OvpnConnectorDeployment `json:"deployment,omitempty"`
+}
+
+// OvpnConnectorProfile describes where the profile of a connector is read from. Exactly one of
+// the sources must be set.
+type OvpnConnectorProfile struct {
+	// A secret in the connector's namespace which contains the profile.
+	SecretRef *OvpnSecretKeyRef `json:"secretRef,omitempty"`
+	// The name of an OvpnClient in the connector's namespace whose profile is used. For clients
+	// with one profile per server, the profile of the client's first server is used.
+	ClientName string `json:"clientName,omitempty"`
+}
+
+// OvpnSecretKeyRef references a key within a secret.
+type OvpnSecretKeyRef struct {
+	// The name of the secret.
+	Name string `json:"name"`
+	// The key within the secret.
+	// +kubebuilder:default=certificate.ovpn
+	Key string `json:"key,omitempty"`
+}
+
+// OvpnConnectorForward describes a TCP port of the connector's service whose connections are
+// forwarded to a host in the remote networks.
+type OvpnConnectorForward struct {
+	// The name of the port within the service. Defaults to `forward-<port>`.
+	Name string `json:"name,omitempty"`
+	// The port of the service.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// The address or DNS name of the remote host. Names are resolved by the cluster's DNS.
+	Host string `json:"host"`
+	// The port of the remote host. Defaults to `port`.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	TargetPort int32 `json:"targetPort,omitempty"`
+}
+
+// OvpnConnectorDeployment describes the deployment of a connector.
+type OvpnConnectorDeployment struct {
+	// The image to use for the connector. Defaults to the server image that the operator is
+	// configured with.
+	Image string `json:"image,omitempty"`
+	// Custom annotations to set on the pod.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnConnectorStatus describes the status of an OVPN connector.
+type OvpnConnectorStatus struct {
+	// The state of the connection. `Pending` while no pod of the connector is running,
+	// `Connecting` until its tunnel is established and `Connected` afterwards. The state follows
+	// the readiness of the connector's pod.
+	State OvpnConnectorState `json:"state,omitempty"`
+	// The address of the connector within the remote VPN. It is queried from the connector's pod
+	// whenever the pod becomes ready.
+	TunnelIP string `json:"tunnelIP,omitempty"`
+	// The reason why the connector is not connected, if known.
+	Message string `json:"message,omitempty"`
+	// The time at which the state last changed.
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
@@ -0,0 +1,55 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectRefDeployment returns a reference to the deployment running the connector.
+func (c *OvpnConnector) ObjectRefDeployment() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-connector", c.Name),
+		Namespace: c.Namespace,
+	}
+}
+
+// ObjectRefConfigMap returns a reference to the configmap carrying the connector's config.
+func (c *OvpnConnector) ObjectRefConfigMap() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-connector", c.Name),
+		Namespace: c.Namespace,
+	}
+}
+
+// ObjectRefService returns a reference to the service exposing the forwarded ports.
+func (c *OvpnConnector) ObjectRefService() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      c.Name,
+		Namespace: c.Namespace,
+	}
+}
+
+// DefaultedKey returns the provided key or `certificate.ovpn`.
+func (r OvpnSecretKeyRef) DefaultedKey() string {
+	if r.Key == "" {
+		return "certificate.ovpn"
+	}
+	return r.Key
+}
+
+// DefaultedName returns the provided name or `forward-<port>`.
+func (f OvpnConnectorForward) DefaultedName() string {
+	if f.Name == "" {
+		return fmt.Sprintf("forward-%d", f.Port)
+	}
+	return f.Name
+}
+
+// DefaultedTargetPort returns the provided target port or the port of the service.
+func (f OvpnConnectorForward) DefaultedTargetPort() int32 {
+	if f.TargetPort == 0 {
+		return f.Port
+	}
+	return f.TargetPort
+}
@@ -0,0 +1,43 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&OvpnPKI{}, &OvpnPKIList{})
+}
+
+// OvpnPKI defines the schema for a PKI that is shared among multiple OVPN servers. Clients of
+// servers sharing a PKI can use a single certificate to connect to all of them.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=ovpnpkis,singular=ovpnpki
+type OvpnPKI struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OvpnPKISpec `json:"spec,omitempty"`
+}
+
+// OvpnPKIList defines the schema for a list of OVPN PKIs.
+// +kubebuilder:object:root=true
+type OvpnPKIList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []OvpnPKI `json:"items"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// OvpnPKISpec describes a shared PKI.
+type OvpnPKISpec struct {
+	OvpnPkiConfig `json:",inline"`
+	// The configuration for the certificates of the servers using the PKI.
+	Server OvpnServerCertificateConfig `json:"server,omitempty"`
+	// The default configuration for the client certificates.
+	Clients OvpnClientCertificateConfig `json:"clients,omitempty"`
+	// The name of the secret containing the TLS key shared by all servers using the PKI. Defaults
+	// to `<pkiname>-shared-secret`.
+	SharedSecretName string `json:"sharedSecretName,omitempty"`
+}
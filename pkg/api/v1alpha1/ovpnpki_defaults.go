@@ -0,0 +1,19 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ObjectRefSharedSecret returns the reference to the secret containing the shared TLS key.
+func (p *OvpnPKI) ObjectRefSharedSecret() metav1.ObjectMeta {
+	ref := metav1.ObjectMeta{
+		Name:      p.Spec.SharedSecretName,
+		Namespace: p.Namespace,
+	}
+	if ref.Name == "" {
+		ref.Name = fmt.Sprintf("%s-shared-secret", p.Name)
+	}
+	return ref
+}
@@ -1,8 +1,11 @@
 package v1alpha1
 
 import (
+	"encoding/json"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func init() {
@@ -12,6 +15,9 @@ func init() {
 // OvpnServer defines the schema for the OVPN server.
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Host",type=string,JSONPath=`.spec.network.host`
+// +kubebuilder:printcolumn:name="Resolved Hosts",type=string,JSONPath=`.status.hosts`,priority=1
+// +kubebuilder:printcolumn:name="Valid",type=string,JSONPath=`.status.conditions[?(@.type=="Valid")].status`
 type OvpnServer struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -35,14 +41,18 @@ type OvpnServerList struct {
 // +kubebuilder:validation:Enum=LoadBalancer;NodePort
 type ServiceType string
 
-// SubnetMask defines an IPv4 range in the form <ip>/<bits>.
-// +kubebuilder:validation:Pattern="^(?:(?:25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9][0-9]|[0-9])\\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9][0-9]|[1-9][0-9]|[0-9])\\/((3[0-2])|([1-2][0-9])|[1-9])$"
+// SubnetMask defines an IPv4 or IPv6 range in the form <ip>/<bits>.
+// +kubebuilder:validation:Format=cidr
 type SubnetMask string
 
 // IPv4Address defines an IPv4 address.
 // +kubebuilder:validation:Format=ipv4
 type IPv4Address string
 
+// IPAddress defines an IPv4 or IPv6 address.
+// +kubebuilder:validation:Pattern="^[0-9a-fA-F.:]+$"
+type IPAddress string
+
 // Hmac defines a message digest algorithm.
 // +kubebuilder:validation:Enum=SHA-384
 type Hmac string
@@ -51,6 +61,57 @@ type Hmac string
 // +kubebuilder:validation:Enum=AES-256-GCM
 type Cipher string
 
+// OvpnReloadPolicy defines how a server applies configuration changes.
+type OvpnReloadPolicy string
+
+const (
+	// OvpnReloadPolicyRestart restarts the pods of the server.
+	OvpnReloadPolicyRestart OvpnReloadPolicy = "Restart"
+	// OvpnReloadPolicyReload sends SIGHUP to the OpenVPN processes of the server.
+	OvpnReloadPolicyReload OvpnReloadPolicy = "Reload"
+)
+
+// OvpnRedirectFlag defines a flag of the redirection of all traffic through the VPN.
+// +kubebuilder:validation:Enum=def1;bypass-dhcp;bypass-dns;block-local;local
+type OvpnRedirectFlag string
+
+const (
+	// OvpnRedirectFlagDef1 overrides the default route by two more specific routes.
+	OvpnRedirectFlagDef1 OvpnRedirectFlag = "def1"
+	// OvpnRedirectFlagBypassDHCP routes the client's DHCP server outside of the VPN.
+	OvpnRedirectFlagBypassDHCP OvpnRedirectFlag = "bypass-dhcp"
+	// OvpnRedirectFlagBypassDNS routes the client's nameservers outside of the VPN.
+	OvpnRedirectFlagBypassDNS OvpnRedirectFlag = "bypass-dns"
+	// OvpnRedirectFlagBlockLocal blocks access to the client's local network.
+	OvpnRedirectFlagBlockLocal OvpnRedirectFlag = "block-local"
+	// OvpnRedirectFlagLocal indicates that client and server are connected via a local network.
+	OvpnRedirectFlagLocal OvpnRedirectFlag = "local"
+)
+
+// OvpnIPv6Mode defines how IPv6 traffic of clients leaves the VPN.
+type OvpnIPv6Mode string
+
+const (
+	// OvpnIPv6ModeNAT masquerades IPv6 traffic with the address of the pod.
+	OvpnIPv6ModeNAT OvpnIPv6Mode = "NAT"
+	// OvpnIPv6ModeRouted forwards IPv6 traffic without translation. The address pool must then be
+	// routed to the pods running the server.
+	OvpnIPv6ModeRouted OvpnIPv6Mode = "Routed"
+)
+
+// OvpnEgressBackend defines the firewall backend which translates the source address of traffic
+// leaving the VPN.
+type OvpnEgressBackend string
+
+const (
+	// OvpnEgressBackendIptablesLegacy uses iptables with the legacy xtables kernel interface.
+	OvpnEgressBackendIptablesLegacy OvpnEgressBackend = "iptables-legacy"
+	// OvpnEgressBackendIptablesNft uses iptables with the nf_tables kernel interface.
+	OvpnEgressBackendIptablesNft OvpnEgressBackend = "iptables-nft"
+	// OvpnEgressBackendNftables uses nft directly.
+	OvpnEgressBackendNftables OvpnEgressBackend = "nftables"
+)
+
 const (
 	// ServiceTypeLoadBalancer uses an external load balancer as entrypoint.
 	ServiceTypeLoadBalancer ServiceType = "LoadBalancer"
@@ -62,6 +123,12 @@ const (
 
 	// CipherAES256GCM defines the AES-256-GCM cipher.
 	CipherAES256GCM Cipher = "AES-256-GCM"
+
+	// HostAuto indicates that the host of a server is derived from its service.
+	HostAuto = "auto"
+	// InterfaceAuto indicates that the egress interface of a server is the interface of the
+	// default route within its pods.
+	InterfaceAuto = "auto"
 )
 
 //-------------------------------------------------------------------------------------------------
@@ -85,12 +152,95 @@ type OvpnServerSpec struct {
 // OvpnServerAddress describes how the OVPN server may be reached.
 type OvpnServerAddress struct {
 	// The host where the server is reachable at. Will also be used as the common name of the
-	// server certificate.
-	Host string `json:"host"`
-	// The protocol used for the OVPN server.
+	// server certificate. If omitted or set to `auto`, the host is derived from the service: the
+	// ingress of a `LoadBalancer` service or the external IPs of the nodes for `NodePort`.
+	Host string `json:"host,omitempty"`
+	// The protocol used for the OVPN server. Ignored if `protocols` is set.
 	// +kubebuilder:default=UDP
 	// +kubebuilder:validation:Enum=TCP;UDP
 	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// The protocols served by the OVPN server simultaneously. Each protocol is served by a
+	// dedicated OpenVPN process with its own address pool. Client profiles list UDP remotes first
+	// and fall back to TCP.
+	// +kubebuilder:validation:MaxItems=2
+	Protocols []OvpnServerProtocol `json:"protocols,omitempty"`
+	// Additional hostnames or IPs where the server is reachable at. Clients fail over to them in
+	// order and they are added to the alternative names of the server certificate.
+	Hosts []OvpnServerHost `json:"hosts,omitempty"`
+	// Whether clients should try the remotes in random order, balancing load across them.
+	// +kubebuilder:default=false
+	RemoteRandom bool `json:"remoteRandom,omitempty"`
+	// The duration after which clients give up connecting to a remote and try the next one.
+	// Defaults to the client's default.
+	ServerPollTimeout metav1.Duration `json:"serverPollTimeout,omitempty"`
+	// A service which receives all traffic arriving at the TCP port that is not OpenVPN traffic,
+	// e.g. an HTTPS ingress. Requires the server to serve TCP. If the port of TCP is not set
+	// explicitly in `protocols`, it defaults to 443.
+	PortShare *OvpnPortShare `json:"portShare,omitempty"`
+	// The configuration for disguising OpenVPN traffic from deep packet inspection.
+	Obfuscation OvpnObfuscationConfig `json:"obfuscation,omitempty"`
+	// The IPv6 configuration of the VPN. If set, clients are assigned IPv6 addresses in addition
+	// to IPv4 addresses and the server is exposed via a dual-stack service.
+	IPv6 *OvpnIPv6Config `json:"ipv6,omitempty"`
+}
+
+// OvpnIPv6Config describes the IPv6 configuration of the VPN.
+type OvpnIPv6Config struct {
+	// The IPv6 range from which the address pools are allocated, one /64 for every protocol and
+	// replica. The prefix must be at most 56 bits long. Defaults to a unique local range.
+	// +kubebuilder:default="fd6d:6565:726b::/48"
+	Pool SubnetMask `json:"pool,omitempty"`
+	// How IPv6 traffic leaves the pods running the server.
+	// +kubebuilder:default=NAT
+	// +kubebuilder:validation:Enum=NAT;Routed
+	Mode OvpnIPv6Mode `json:"mode,omitempty"`
+}
+
+// OvpnPortShare describes a cluster service that shares the TCP port with the OVPN server.
+type OvpnPortShare struct {
+	// The name of the service.
+	ServiceName string `json:"serviceName"`
+	// The namespace of the service. Defaults to the namespace of the server.
+	Namespace string `json:"namespace,omitempty"`
+	// The port of the service to forward traffic to.
+	// +kubebuilder:default=443
+	Port uint16 `json:"port,omitempty"`
+}
+
+// OvpnServerProtocol describes a protocol served by the OVPN server.
+type OvpnServerProtocol struct {
+	// The protocol.
+	// +kubebuilder:validation:Enum=TCP;UDP
+	Protocol corev1.Protocol `json:"protocol"`
+	// The port at which the protocol is exposed by the service. Defaults to the service port.
+	Port uint16 `json:"port,omitempty"`
+}
+
+// OvpnObfuscationConfig describes how OpenVPN traffic is disguised.
+type OvpnObfuscationConfig struct {
+	// Wraps the TCP transport of the server in TLS which is terminated by a stunnel sidecar.
+	// Requires the server to serve TCP. Clients connect via a local stunnel client.
+	TLSWrap *OvpnTLSWrap `json:"tlsWrap,omitempty"`
+}
+
+// OvpnTLSWrap describes the TLS endpoint wrapping the TCP transport of the server.
+type OvpnTLSWrap struct {
+	// The port at which the TLS endpoint is exposed by the service. Must differ from the port of
+	// TCP.
+	// +kubebuilder:default=443
+	Port uint16 `json:"port,omitempty"`
+}
+
+// OvpnServerHost describes an additional host where the OVPN server may be reached.
+type OvpnServerHost struct {
+	// The hostname or IP.
+	Host string `json:"host"`
+	// The port at which the server is reachable at this host. Defaults to the port of the
+	// protocol.
+	Port uint16 `json:"port,omitempty"`
+	// The protocol to use for this host. Defaults to all protocols served by the server.
+	// +kubebuilder:validation:Enum=TCP;UDP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
 }
 
 // OvpnTrafficConfig defines the configuration of how traffic flows through the VPN.
@@ -98,12 +248,111 @@ type OvpnTrafficConfig struct {
 	// Whether all traffic should be routed through the VPN.
 	// +kubebuilder:default=false
 	RedirectAll bool `json:"redirectAll,omitempty"`
+	// The flags of the redirection if `redirectAll` is set. Defaults to `def1` which overrides
+	// the default route without replacing it.
+	RedirectFlags []OvpnRedirectFlag `json:"redirectFlags,omitempty"`
 	// Defines a list of (target) IP ranges for which traffic is routed through the VPN. Ignored if
+	// `redirectAll` is set. IPv6 ranges require `network.ipv6` to be set.
+	Routes []OvpnRoute `json:"routes,omitempty"`
+	// Defines a list of IPv4 ranges for which traffic bypasses the VPN, e.g. local networks or
+	// the ranges of latency-sensitive services. Mostly useful if `redirectAll` is set.
+	ExcludedRoutes []SubnetMask `json:"excludedRoutes,omitempty"`
+	// Selectors for services whose cluster IPs and endpoints are routed through the VPN as
+	// individual addresses. Routes are updated as services and their endpoints change. Ignored if
 	// `redirectAll` is set.
-	Routes []SubnetMask `json:"routes,omitempty"`
-	// Defines a list of nameservers to use for name resolution.
-	// +kubebuilder:default={"8.8.4.4","8.8.8.8"}
-	Nameservers []IPv4Address `json:"nameservers,omitempty"`
+	RouteSelectors []OvpnRouteSelector `json:"routeSelectors,omitempty"`
+	// Whether the pod CIDRs of all nodes and the service CIDRs that the operator is configured
+	// with are routed through the VPN. Ignored if `redirectAll` is set.
+	// +kubebuilder:default=false
+	RouteClusterCIDRs bool `json:"routeClusterCIDRs,omitempty"`
+	// Whether clients can reach each other. Traffic between clients is then passed on by the
+	// OpenVPN server directly and bypasses the firewall of the server's pod.
+	// +kubebuilder:default=false
+	ClientToClient bool `json:"clientToClient,omitempty"`
+	// Defines a list of nameservers to use for name resolution. IPv6 nameservers require
+	// `network.ipv6` to be set. Defaults to Google's nameservers unless the cluster DNS is used.
+	Nameservers []IPAddress `json:"nameservers,omitempty"`
+	// The DNS configuration pushed to clients.
+	DNS OvpnDNSConfig `json:"dns,omitempty"`
+	// The configuration of how traffic leaves the pods running the server.
+	Egress OvpnEgressConfig `json:"egress,omitempty"`
+}
+
+// OvpnRoute describes an IP range for which traffic is routed through the VPN. As in earlier
+// versions, a route may also be given as a plain IP range. The schema therefore does not enforce
+// the type of routes, plain IP ranges are validated by the operator.
+// +kubebuilder:validation:Type=""
+// +kubebuilder:pruning:PreserveUnknownFields
+type OvpnRoute struct {
+	// The IP range.
+	Network SubnetMask `json:"network"`
+	// The metric of the route on clients. Defaults to the default metric of the client.
+	// +kubebuilder:validation:Minimum=0
+	Metric *int32 `json:"metric,omitempty"`
+}
+
+// UnmarshalJSON reads a route which may also be given as a plain IP range, as routes were listed
+// in earlier versions. As the schema cannot enforce the type of routes, values of any other type
+// are kept as invalid networks such that the operator rejects them instead of failing to read the
+// server.
+func (r *OvpnRoute) UnmarshalJSON(data []byte) error {
+	var network string
+	if err := json.Unmarshal(data, &network); err == nil {
+		*r = OvpnRoute{Network: SubnetMask(network)}
+		return nil
+	}
+	type plain OvpnRoute
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		*r = OvpnRoute{Network: SubnetMask(data)}
+	}
+	return nil
+}
+
+// OvpnDNSConfig describes how clients resolve names while connected to the VPN.
+type OvpnDNSConfig struct {
+	// Whether the cluster's DNS service is used as the first nameserver. Its address is routed
+	// through the VPN.
+	// +kubebuilder:default=false
+	UseClusterDNS bool `json:"useClusterDNS,omitempty"`
+	// Domains which clients append to unqualified names, e.g. `svc.cluster.local`. The first
+	// domain is additionally pushed as the connection's domain.
+	SearchDomains []string `json:"searchDomains,omitempty"`
+	// Domains which are resolved through the VPN's nameservers. If set, clients supporting split
+	// DNS resolve all other names using their own nameservers.
+	SplitDomains []string `json:"splitDomains,omitempty"`
+	// Whether Windows clients block DNS queries outside the VPN to prevent DNS leaks. Cannot be
+	// combined with `splitDomains`.
+	// +kubebuilder:default=false
+	BlockOutsideDNS bool `json:"blockOutsideDNS,omitempty"`
+}
+
+// OvpnRouteSelector selects services whose addresses are routed through the VPN.
+type OvpnRouteSelector struct {
+	// The labels of the services. If omitted, all services in the selected namespaces are
+	// selected.
+	ServiceSelector *metav1.LabelSelector `json:"serviceSelector,omitempty"`
+	// The labels of the namespaces to select services from. Defaults to the namespace of the
+	// server.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// OvpnEgressConfig defines how traffic of clients leaves the pods running the server. By default,
+// it is masqueraded with the address of the pod's interface.
+type OvpnEgressConfig struct {
+	// The network interface that traffic leaves through, e.g. a secondary interface attached by
+	// Multus. Set to `auto` to use the interface of the pod's default route.
+	// +kubebuilder:default=eth0
+	// +kubebuilder:validation:MaxLength=15
+	Interface string `json:"interface,omitempty"`
+	// The firewall backend used to translate source addresses. Must match the backend in use on
+	// the node if other components manage rules as well.
+	// +kubebuilder:default=iptables-legacy
+	// +kubebuilder:validation:Enum=iptables-legacy;iptables-nft;nftables
+	Backend OvpnEgressBackend `json:"backend,omitempty"`
+	// A fixed source address for traffic leaving through the interface, e.g. to allowlist the
+	// VPN in downstream firewalls. The address must be assigned to the interface. Defaults to
+	// masquerading with the interface's primary address.
+	SNATAddress IPv4Address `json:"snatAddress,omitempty"`
 }
 
 // OvpnSecurityConfig encapsulates security configuration of the OVPN server.
@@ -120,6 +369,9 @@ type OvpnSecurityConfig struct {
 	DiffieHellmanBits int `json:"diffieHellmanBits,omitempty"`
 	// The configuration of the PKI.
 	PKI OvpnPkiConfig `json:"pki,omitempty"`
+	// The name of an OvpnPKI in the same namespace to use instead of a dedicated PKI. If set, the
+	// `pki` configuration is ignored and clients can connect to all servers sharing the PKI.
+	PKIName string `json:"pkiName,omitempty"`
 	// The configuration for the server certificates.
 	Server OvpnServerCertificateConfig `json:"server,omitempty"`
 	// The default configuration for the client certificates.
@@ -131,6 +383,9 @@ type OvpnPkiConfig struct {
 	OvpnPKICertificateConfig `json:",inline"`
 	// The configuration for the distinguished name.
 	DN OvpnPkiDnConfig `json:"dn,omitempty"`
+	// The issuer describing the Vault instance which hosts the PKI. Defaults to the Vault
+	// instance that the operator is configured with.
+	IssuerRef *VaultIssuerReference `json:"issuerRef,omitempty"`
 }
 
 // OvpnPkiDnConfig describes the configuration of the distinguished name.
@@ -161,10 +416,23 @@ type OvpnServerSecrets struct {
 
 // OvpnServerDeployment describes the deployment configuration of the server.
 type OvpnServerDeployment struct {
-	// The name of the deployment. Defaults to the name of the server.
+	// The name of the stateful set running the server. Defaults to the name of the server.
 	Name string `json:"name,omitempty"`
-	// Custom annotations to set on the deployment.
+	// Custom annotations to set on the stateful set.
 	Annotations map[string]string `json:"annotations,omitempty"`
+	// The number of replicas running the server. Each replica serves a distinct slice of the
+	// address pool. Replicas are updated one at a time, so updates only disconnect the clients of
+	// a single replica if there are at least two.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	Replicas int32 `json:"replicas,omitempty"`
+	// How the server applies changes to its config and shared secrets. `Restart` restarts the
+	// pods while `Reload` signals OpenVPN to reload them in place. Changes to the entrypoint
+	// always restart the pods.
+	// +kubebuilder:default=Restart
+	// +kubebuilder:validation:Enum=Restart;Reload
+	ReloadPolicy OvpnReloadPolicy `json:"reloadPolicy,omitempty"`
 	// Custom annotations to set on the pod.
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 	// The name of the configmap to be used for the OpenVPN config. Defaults to
@@ -172,6 +440,15 @@ type OvpnServerDeployment struct {
 	OvpnConfigMapName string `json:"ovpnConfigMapName,omitempty"`
 	// The name of the configmap to carry the OpenVPN setup. Defaults to `<servername>-entrypoint`.
 	EntrypointConfigMapName string `json:"entrypointConfigMapName,omitempty"`
+	// The image to use for the server. Defaults to the image that the operator is configured
+	// with.
+	Image string `json:"image,omitempty"`
+	// A pod template which is applied as strategic merge patch on top of the generated pod
+	// template, e.g. to set resources, a node selector or tolerations. The server's container is
+	// named `openvpn`.
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplate *runtime.RawExtension `json:"podTemplate,omitempty"`
 }
 
 // OvpnServerService describes the service configuration of the OVPN server.
@@ -181,17 +458,38 @@ type OvpnServerService struct {
 	// Custom annotations to set on the service.
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// The port that the server should be running on. For `serviceType` set to `NodePort`, this
-	// value must be in the range [30000, 32767].
-	// +kubebuilder:default=1194
+	// value must be in the range [30000, 32767]. Defaults to 1194, or to 443 for TCP if the port
+	// is shared with another service. The default is applied by the operator rather than the API
+	// server such that an unset port can be told apart from an explicit one.
 	Port uint16 `json:"port,omitempty"`
 	// The type for the Kubernetes servce.
 	// +kubebuilder:default=LoadBalancer
 	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
 	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Whether a `LoadBalancer` service may expose multiple protocols. This requires support by the
+	// cloud provider. If disabled, all protocols but the first one are exposed by additional
+	// services named `<servicename>-<protocol>`. When the host is derived automatically, these
+	// services must share the IP of the first one, e.g. via provider-specific annotations.
+	// +kubebuilder:default=false
+	MixedProtocol bool `json:"mixedProtocol,omitempty"`
+	// The IP family policy of the service. Defaults to `PreferDualStack` if `network.ipv6` is set
+	// and the cluster's default otherwise.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
 }
 
 //-------------------------------------------------------------------------------------------------
 
+// OvpnServerConditionValid is the type of the condition reporting whether a server's spec is
+// valid.
+const OvpnServerConditionValid = "Valid"
+
 // OvpnServerStatus describes the status of an OVPN server.
 type OvpnServerStatus struct {
+	// The hosts where the server was found to be reachable at if its host is derived
+	// automatically. The first host is used as the common name of the server certificate.
+	Hosts []string `json:"hosts,omitempty"`
+	// The conditions of the server. The `Valid` condition reports whether the server's spec is
+	// consistent. Servers with an invalid spec are not reconciled until it is fixed.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
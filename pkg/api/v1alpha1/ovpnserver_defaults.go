@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -68,7 +69,7 @@ func (s *OvpnServer) ObjectRefEntrypointConfigMap() metav1.ObjectMeta {
 	return ref
 }
 
-// ObjectRefDeployment returns a reference to the deployment.
+// ObjectRefDeployment returns a reference to the stateful set running the server.
 func (s *OvpnServer) ObjectRefDeployment() metav1.ObjectMeta {
 	ref := metav1.ObjectMeta{
 		Name:      s.Spec.Deployment.Name,
@@ -92,6 +93,34 @@ func (s *OvpnServer) ObjectRefService() metav1.ObjectMeta {
 	return ref
 }
 
+// ObjectRefHeadlessService returns a reference to the headless service governing the stateful set
+// of the server which provides DNS names for its individual pods.
+func (s *OvpnServer) ObjectRefHeadlessService() metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-headless", s.ObjectRefDeployment().Name),
+		Namespace: s.Namespace,
+	}
+}
+
+// ObjectRefProtocolService returns a reference to the additional service exposing the VPN for the
+// given protocol.
+func (s *OvpnServer) ObjectRefProtocolService(protocol corev1.Protocol) metav1.ObjectMeta {
+	ref := s.ObjectRefService()
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%s", ref.Name, strings.ToLower(string(protocol))),
+		Namespace: ref.Namespace,
+	}
+}
+
+// ObjectRefCertificate returns a reference to the record of the certificate with the given serial
+// which has been issued by the server's PKI.
+func (s *OvpnServer) ObjectRefCertificate(serial string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%s", s.Name, strings.ReplaceAll(serial, ":", "")),
+		Namespace: s.Namespace,
+	}
+}
+
 //-------------------------------------------------------------------------------------------------
 
 // DefaultedProtocol returns the provided protocol or UDP if none is provided.
@@ -102,9 +131,157 @@ func (a OvpnServerAddress) DefaultedProtocol() corev1.Protocol {
 	return a.Protocol
 }
 
+// IsHostAuto returns whether the host of the server is derived from its service.
+func (s *OvpnServer) IsHostAuto() bool {
+	return s.Spec.Network.Host == "" || s.Spec.Network.Host == HostAuto
+}
+
+// PrimaryHost returns the host where the server is reachable at. If the host is derived
+// automatically, it is empty until the host has been discovered.
+func (s *OvpnServer) PrimaryHost() string {
+	if !s.IsHostAuto() {
+		return s.Spec.Network.Host
+	}
+	if len(s.Status.Hosts) > 0 {
+		return s.Status.Hosts[0]
+	}
+	return ""
+}
+
+// DefaultedProtocols returns the distinct protocols served by the server along with the ports at
+// which they are exposed. UDP is always listed first. Ports which are not set explicitly default
+// to the service's port, TCP defaults to port 443 if the port is shared with another service.
+func (s *OvpnServer) DefaultedProtocols() []OvpnServerProtocol {
+	protocols := s.Spec.Network.Protocols
+	if len(protocols) == 0 {
+		// The service's port has no schema default and is only set if it is configured
+		// explicitly such that the defaults below apply
+		protocols = []OvpnServerProtocol{{
+			Protocol: s.Spec.Network.DefaultedProtocol(),
+			Port:     s.Spec.Service.Port,
+		}}
+	}
+	result := []OvpnServerProtocol{}
+	for _, candidate := range []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP} {
+		for _, protocol := range protocols {
+			if protocol.Protocol == candidate {
+				if protocol.Port == 0 && protocol.Protocol == corev1.ProtocolTCP &&
+					s.Spec.Network.PortShare != nil {
+					protocol.Port = 443
+				}
+				if protocol.Port == 0 {
+					protocol.Port = s.Spec.Service.DefaultedPort()
+				}
+				result = append(result, protocol)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// allHosts returns all hosts where the server is reachable at, starting with the primary host,
+// followed by other discovered hosts and the additional hosts.
+func (s *OvpnServer) allHosts() []OvpnServerHost {
+	hosts := []OvpnServerHost{}
+	if s.IsHostAuto() {
+		for _, host := range s.Status.Hosts {
+			hosts = append(hosts, OvpnServerHost{Host: host})
+		}
+	} else {
+		hosts = append(hosts, OvpnServerHost{Host: s.Spec.Network.Host})
+	}
+	return append(hosts, s.Spec.Network.Hosts...)
+}
+
+// Remotes returns the remotes that clients connect to. Hosts without an explicit protocol are
+// listed once per protocol served by the server, UDP remotes precede TCP remotes. Ports that are
+// not set explicitly are taken from the protocol.
+func (s *OvpnServer) Remotes() []OvpnServerHost {
+	ports := map[corev1.Protocol]uint16{}
+	for _, protocol := range s.DefaultedProtocols() {
+		ports[protocol.Protocol] = protocol.Port
+	}
+	result := []OvpnServerHost{}
+	for _, protocol := range []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP} {
+		port, served := ports[protocol]
+		for _, host := range s.allHosts() {
+			if host.Protocol != protocol && (host.Protocol != "" || !served) {
+				continue
+			}
+			host.Protocol = protocol
+			if host.Port == 0 {
+				host.Port = port
+			}
+			if host.Port == 0 {
+				host.Port = s.Spec.Service.DefaultedPort()
+			}
+			result = append(result, host)
+		}
+	}
+	return result
+}
+
+// AlternativeNames returns the distinct hosts of the server which are not the primary host.
+func (s *OvpnServer) AlternativeNames() []string {
+	result := []string{}
+	seen := map[string]bool{s.PrimaryHost(): true}
+	for _, host := range s.allHosts() {
+		if !seen[host.Host] {
+			result = append(result, host.Host)
+			seen[host.Host] = true
+		}
+	}
+	return result
+}
+
+// Host returns the cluster-internal DNS name of the service sharing the port with the server.
+func (p OvpnPortShare) Host(namespace string) string {
+	if p.Namespace != "" {
+		namespace = p.Namespace
+	}
+	return fmt.Sprintf("%s.%s.svc", p.ServiceName, namespace)
+}
+
+// DefaultedPort returns the port of the service sharing the port with the server.
+func (p OvpnPortShare) DefaultedPort() uint16 {
+	if p.Port == 0 {
+		return 443
+	}
+	return p.Port
+}
+
+// DefaultedPort returns the port at which the TLS endpoint is exposed.
+func (w OvpnTLSWrap) DefaultedPort() uint16 {
+	if w.Port == 0 {
+		return 443
+	}
+	return w.Port
+}
+
+// DefaultedRedirectFlags returns the flags of the redirection of all traffic or `def1` if none
+// are provided.
+func (c OvpnTrafficConfig) DefaultedRedirectFlags() []string {
+	if len(c.RedirectFlags) == 0 {
+		return []string{string(OvpnRedirectFlagDef1)}
+	}
+	result := []string{}
+	for _, flag := range c.RedirectFlags {
+		result = append(result, string(flag))
+	}
+	return result
+}
+
+// HasDynamicRoutes returns whether any routes are derived from other objects in the cluster.
+func (c OvpnTrafficConfig) HasDynamicRoutes() bool {
+	return !c.RedirectAll &&
+		(len(c.RouteSelectors) > 0 || c.RouteClusterCIDRs || c.DNS.UseClusterDNS)
+}
+
 // DefaultedNameservers returns the provided nameservers or standard Google nameservers otherwise.
+// If the cluster DNS is used, there are no default nameservers.
 func (c OvpnTrafficConfig) DefaultedNameservers() []string {
-	if c.Nameservers == nil || len(c.Nameservers) == 0 {
+	if len(c.Nameservers) == 0 && !c.DNS.UseClusterDNS {
 		return []string{"8.8.4.4", "8.8.8.8"}
 	}
 	result := []string{}
@@ -114,6 +291,38 @@ func (c OvpnTrafficConfig) DefaultedNameservers() []string {
 	return result
 }
 
+// DefaultedPool returns the IPv6 range of the address pools.
+func (c OvpnIPv6Config) DefaultedPool() SubnetMask {
+	if c.Pool == "" {
+		return "fd6d:6565:726b::/48"
+	}
+	return c.Pool
+}
+
+// DefaultedMode returns how IPv6 traffic leaves the VPN, translating it by default.
+func (c OvpnIPv6Config) DefaultedMode() OvpnIPv6Mode {
+	if c.Mode == "" {
+		return OvpnIPv6ModeNAT
+	}
+	return c.Mode
+}
+
+// DefaultedInterface returns the egress interface or `eth0` if none is provided.
+func (c OvpnEgressConfig) DefaultedInterface() string {
+	if c.Interface == "" {
+		return "eth0"
+	}
+	return c.Interface
+}
+
+// DefaultedBackend returns the egress backend or legacy iptables if none is provided.
+func (c OvpnEgressConfig) DefaultedBackend() OvpnEgressBackend {
+	if c.Backend == "" {
+		return OvpnEgressBackendIptablesLegacy
+	}
+	return c.Backend
+}
+
 // DefaultedHmac returns the provided Hmac or SHA-384.
 func (c OvpnSecurityConfig) DefaultedHmac() Hmac {
 	if c.Hmac == "" {
@@ -138,6 +347,22 @@ func (c OvpnPkiDnConfig) DefaultedCommonName() string {
 	return c.CommonName
 }
 
+// DefaultedReplicas returns the number of replicas running the server.
+func (d OvpnServerDeployment) DefaultedReplicas() int32 {
+	if d.Replicas < 1 {
+		return 1
+	}
+	return d.Replicas
+}
+
+// DefaultedReloadPolicy returns the reload policy of the server, restarting pods by default.
+func (d OvpnServerDeployment) DefaultedReloadPolicy() OvpnReloadPolicy {
+	if d.ReloadPolicy == "" {
+		return OvpnReloadPolicyRestart
+	}
+	return d.ReloadPolicy
+}
+
 // DefaultedPort returns the port of the service.
 func (s OvpnServerService) DefaultedPort() uint16 {
 	if s.Port == 0 {
@@ -146,6 +371,19 @@ func (s OvpnServerService) DefaultedPort() uint16 {
 	return s.Port
 }
 
+// IPFamilyPolicy returns the IP family policy of the server's service. It is nil if the cluster's
+// default should be used.
+func (s *OvpnServer) IPFamilyPolicy() *corev1.IPFamilyPolicyType {
+	if s.Spec.Service.IPFamilyPolicy != nil {
+		return s.Spec.Service.IPFamilyPolicy
+	}
+	if s.Spec.Network.IPv6 != nil {
+		policy := corev1.IPFamilyPolicyPreferDualStack
+		return &policy
+	}
+	return nil
+}
+
 // DefaultedServiceType returns the service type of the service.
 func (s OvpnServerService) DefaultedServiceType() corev1.ServiceType {
 	if s.ServiceType == "" {
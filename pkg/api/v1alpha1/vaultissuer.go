@@ -0,0 +1,154 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	SchemeBuilder.Register(&VaultIssuer{}, &VaultIssuerList{})
+	SchemeBuilder.Register(&ClusterVaultIssuer{}, &ClusterVaultIssuerList{})
+}
+
+// VaultIssuer describes a connection to a Vault instance which hosts the PKIs of OVPN servers. It
+// can only be referenced by servers in the same namespace.
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.spec.address`
+// +kubebuilder:printcolumn:name="Auth",type=string,JSONPath=`.spec.auth.method`
+type VaultIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultIssuerSpec `json:"spec"`
+}
+
+// VaultIssuerList defines the schema for a list of Vault issuers.
+// +kubebuilder:object:root=true
+type VaultIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []VaultIssuer `json:"items"`
+}
+
+// ClusterVaultIssuer describes a connection to a Vault instance just like a VaultIssuer. However,
+// it can be referenced by servers in all namespaces.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Address",type=string,JSONPath=`.spec.address`
+// +kubebuilder:printcolumn:name="Auth",type=string,JSONPath=`.spec.auth.method`
+type ClusterVaultIssuer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VaultIssuerSpec `json:"spec"`
+}
+
+// ClusterVaultIssuerList defines the schema for a list of cluster Vault issuers.
+// +kubebuilder:object:root=true
+type ClusterVaultIssuerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterVaultIssuer `json:"items"`
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// VaultAuthMethod defines how the operator authenticates against Vault.
+// +kubebuilder:validation:Enum=kubernetes;approle;token
+type VaultAuthMethod string
+
+const (
+	// VaultAuthMethodKubernetes logs in using a service account token.
+	VaultAuthMethodKubernetes VaultAuthMethod = "kubernetes"
+	// VaultAuthMethodAppRole logs in using a role ID and a secret ID.
+	VaultAuthMethodAppRole VaultAuthMethod = "approle"
+	// VaultAuthMethodToken uses a static token.
+	VaultAuthMethodToken VaultAuthMethod = "token"
+)
+
+// VaultIssuerKind defines the kind of an issuer that is referenced.
+// +kubebuilder:validation:Enum=VaultIssuer;ClusterVaultIssuer
+type VaultIssuerKind string
+
+const (
+	// VaultIssuerKindNamespaced references a VaultIssuer.
+	VaultIssuerKindNamespaced VaultIssuerKind = "VaultIssuer"
+	// VaultIssuerKindCluster references a ClusterVaultIssuer.
+	VaultIssuerKindCluster VaultIssuerKind = "ClusterVaultIssuer"
+)
+
+//-------------------------------------------------------------------------------------------------
+
+// VaultIssuerSpec describes how to connect to a Vault instance.
+type VaultIssuerSpec struct {
+	// The address of the Vault instance.
+	Address string `json:"address"`
+	// The PEM-encoded CA certificate to verify the Vault instance with. Defaults to the system's
+	// certificate pool.
+	CABundle string `json:"caBundle,omitempty"`
+	// The server name to verify the Vault instance's certificate against.
+	ServerName string `json:"serverName,omitempty"`
+	// The Vault Enterprise namespace in which the PKIs are managed.
+	Namespace string `json:"namespace,omitempty"`
+	// The base path for mounting PKIs of the OVPN servers. Defaults to the operator's global PKI
+	// path.
+	PKIPath string `json:"pkiPath,omitempty"`
+	// The configuration for authenticating against Vault.
+	Auth VaultIssuerAuth `json:"auth"`
+}
+
+// VaultIssuerAuth describes how to authenticate against Vault.
+type VaultIssuerAuth struct {
+	// The auth method to use.
+	// +kubebuilder:default=kubernetes
+	Method VaultAuthMethod `json:"method,omitempty"`
+	// The path at which the auth method is mounted. Defaults to `auth/<method>`.
+	MountPath string `json:"mountPath,omitempty"`
+	// The role to log in with for the `kubernetes` method.
+	Role string `json:"role,omitempty"`
+	// The service account whose token is used to log in with the `kubernetes` method. Required
+	// for a VaultIssuer as namespaced issuers must not log in with the operator's own token. A
+	// ClusterVaultIssuer logs in with the operator's token if it is not set.
+	ServiceAccountRef *VaultServiceAccountReference `json:"serviceAccountRef,omitempty"`
+	// The role ID to log in with for the `approle` method.
+	RoleID string `json:"roleId,omitempty"`
+	// The secret containing the secret ID for the `approle` method or the token for the `token`
+	// method.
+	SecretRef *VaultSecretReference `json:"secretRef,omitempty"`
+}
+
+// VaultSecretReference references a key within a secret.
+type VaultSecretReference struct {
+	// The name of the secret.
+	Name string `json:"name"`
+	// The namespace of the secret. Defaults to the operator's namespace for a ClusterVaultIssuer,
+	// ignored for a VaultIssuer which always references secrets in its own namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// The key within the secret.
+	Key string `json:"key"`
+}
+
+// VaultServiceAccountReference references a service account for which short-lived tokens are
+// requested. The service account must opt in by granting the operator `create` on its
+// `serviceaccounts/token` subresource.
+type VaultServiceAccountReference struct {
+	// The name of the service account.
+	Name string `json:"name"`
+	// The namespace of the service account. Defaults to the operator's namespace for a
+	// ClusterVaultIssuer, ignored for a VaultIssuer which always references service accounts in
+	// its own namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// The audiences of the requested tokens, all of which must start with `vault://`. Defaults to
+	// `vault://<namespace>/<name>` of a VaultIssuer and `vault://<name>` of a ClusterVaultIssuer.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// VaultIssuerReference references a VaultIssuer or a ClusterVaultIssuer.
+type VaultIssuerReference struct {
+	// The name of the issuer.
+	Name string `json:"name"`
+	// The kind of the issuer.
+	// +kubebuilder:default=VaultIssuer
+	Kind VaultIssuerKind `json:"kind,omitempty"`
+}
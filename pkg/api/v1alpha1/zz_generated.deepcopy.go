@@ -5,9 +5,96 @@
 package v1alpha1
 
 import (
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVaultIssuer) DeepCopyInto(out *ClusterVaultIssuer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVaultIssuer.
+func (in *ClusterVaultIssuer) DeepCopy() *ClusterVaultIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVaultIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVaultIssuer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVaultIssuerList) DeepCopyInto(out *ClusterVaultIssuerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterVaultIssuer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVaultIssuerList.
+func (in *ClusterVaultIssuerList) DeepCopy() *ClusterVaultIssuerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVaultIssuerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterVaultIssuerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnCertificate) DeepCopyInto(out *OvpnCertificate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnCertificate.
+func (in *OvpnCertificate) DeepCopy() *OvpnCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnCertificate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnCertificateConfig) DeepCopyInto(out *OvpnCertificateConfig) {
 	*out = *in
@@ -24,13 +111,81 @@ func (in *OvpnCertificateConfig) DeepCopy() *OvpnCertificateConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnCertificateList) DeepCopyInto(out *OvpnCertificateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OvpnCertificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnCertificateList.
+func (in *OvpnCertificateList) DeepCopy() *OvpnCertificateList {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnCertificateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnCertificateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnCertificateSpec) DeepCopyInto(out *OvpnCertificateSpec) {
+	*out = *in
+	in.NotBefore.DeepCopyInto(&out.NotBefore)
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnCertificateSpec.
+func (in *OvpnCertificateSpec) DeepCopy() *OvpnCertificateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnCertificateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnCertificateStatus) DeepCopyInto(out *OvpnCertificateStatus) {
+	*out = *in
+	if in.RevokedAt != nil {
+		in, out := &in.RevokedAt, &out.RevokedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnCertificateStatus.
+func (in *OvpnCertificateStatus) DeepCopy() *OvpnCertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnCertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnClient) DeepCopyInto(out *OvpnClient) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClient.
@@ -52,63 +207,467 @@ func (in *OvpnClient) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnClientCertificate) DeepCopyInto(out *OvpnClientCertificate) {
+func (in *OvpnClientCertificate) DeepCopyInto(out *OvpnClientCertificate) {
+	*out = *in
+	out.OvpnCertificateConfig = in.OvpnCertificateConfig
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientCertificate.
+func (in *OvpnClientCertificate) DeepCopy() *OvpnClientCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnClientCertificateConfig) DeepCopyInto(out *OvpnClientCertificateConfig) {
+	*out = *in
+	out.OvpnCertificateConfig = in.OvpnCertificateConfig
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientCertificateConfig.
+func (in *OvpnClientCertificateConfig) DeepCopy() *OvpnClientCertificateConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientCertificateConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnClientGateway) DeepCopyInto(out *OvpnClientGateway) {
+	*out = *in
+	if in.Subnets != nil {
+		in, out := &in.Subnets, &out.Subnets
+		*out = make([]SubnetMask, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientGateway.
+func (in *OvpnClientGateway) DeepCopy() *OvpnClientGateway {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientGateway)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnClientList) DeepCopyInto(out *OvpnClientList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OvpnClient, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientList.
+func (in *OvpnClientList) DeepCopy() *OvpnClientList {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnClientList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnClientSpec) DeepCopyInto(out *OvpnClientSpec) {
+	*out = *in
+	if in.ServerNames != nil {
+		in, out := &in.ServerNames, &out.ServerNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Certificate = in.Certificate
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	out.AccessWindow = in.AccessWindow
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(OvpnClientGateway)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientSpec.
+func (in *OvpnClientSpec) DeepCopy() *OvpnClientSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnClientStatus) DeepCopyInto(out *OvpnClientStatus) {
+	*out = *in
+	if in.LastRotation != nil {
+		in, out := &in.LastRotation, &out.LastRotation
+		*out = (*in).DeepCopy()
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]OvpnIssuedCertificate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientStatus.
+func (in *OvpnClientStatus) DeepCopy() *OvpnClientStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnClientStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnector) DeepCopyInto(out *OvpnConnector) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnector.
+func (in *OvpnConnector) DeepCopy() *OvpnConnector {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnConnector) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorDeployment) DeepCopyInto(out *OvpnConnectorDeployment) {
+	*out = *in
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorDeployment.
+func (in *OvpnConnectorDeployment) DeepCopy() *OvpnConnectorDeployment {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorDeployment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorForward) DeepCopyInto(out *OvpnConnectorForward) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorForward.
+func (in *OvpnConnectorForward) DeepCopy() *OvpnConnectorForward {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorForward)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorList) DeepCopyInto(out *OvpnConnectorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OvpnConnector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorList.
+func (in *OvpnConnectorList) DeepCopy() *OvpnConnectorList {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnConnectorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorProfile) DeepCopyInto(out *OvpnConnectorProfile) {
+	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(OvpnSecretKeyRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorProfile.
+func (in *OvpnConnectorProfile) DeepCopy() *OvpnConnectorProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorSpec) DeepCopyInto(out *OvpnConnectorSpec) {
+	*out = *in
+	in.Profile.DeepCopyInto(&out.Profile)
+	if in.Forwards != nil {
+		in, out := &in.Forwards, &out.Forwards
+		*out = make([]OvpnConnectorForward, len(*in))
+		copy(*out, *in)
+	}
+	in.Deployment.DeepCopyInto(&out.Deployment)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorSpec.
+func (in *OvpnConnectorSpec) DeepCopy() *OvpnConnectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnConnectorStatus) DeepCopyInto(out *OvpnConnectorStatus) {
+	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnConnectorStatus.
+func (in *OvpnConnectorStatus) DeepCopy() *OvpnConnectorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnConnectorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnDNSConfig) DeepCopyInto(out *OvpnDNSConfig) {
+	*out = *in
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SplitDomains != nil {
+		in, out := &in.SplitDomains, &out.SplitDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnDNSConfig.
+func (in *OvpnDNSConfig) DeepCopy() *OvpnDNSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnDNSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnEgressConfig) DeepCopyInto(out *OvpnEgressConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnEgressConfig.
+func (in *OvpnEgressConfig) DeepCopy() *OvpnEgressConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnEgressConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnIPv6Config) DeepCopyInto(out *OvpnIPv6Config) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnIPv6Config.
+func (in *OvpnIPv6Config) DeepCopy() *OvpnIPv6Config {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnIPv6Config)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnIssuedCertificate) DeepCopyInto(out *OvpnIssuedCertificate) {
+	*out = *in
+	in.IssuedAt.DeepCopyInto(&out.IssuedAt)
+	in.ExpiresAt.DeepCopyInto(&out.ExpiresAt)
+	if in.RevokedAt != nil {
+		in, out := &in.RevokedAt, &out.RevokedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnIssuedCertificate.
+func (in *OvpnIssuedCertificate) DeepCopy() *OvpnIssuedCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnIssuedCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnObfuscationConfig) DeepCopyInto(out *OvpnObfuscationConfig) {
+	*out = *in
+	if in.TLSWrap != nil {
+		in, out := &in.TLSWrap, &out.TLSWrap
+		*out = new(OvpnTLSWrap)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnObfuscationConfig.
+func (in *OvpnObfuscationConfig) DeepCopy() *OvpnObfuscationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnObfuscationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnPKI) DeepCopyInto(out *OvpnPKI) {
 	*out = *in
-	out.OvpnCertificateConfig = in.OvpnCertificateConfig
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientCertificate.
-func (in *OvpnClientCertificate) DeepCopy() *OvpnClientCertificate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPKI.
+func (in *OvpnPKI) DeepCopy() *OvpnPKI {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnClientCertificate)
+	out := new(OvpnPKI)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvpnPKI) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnClientCertificateConfig) DeepCopyInto(out *OvpnClientCertificateConfig) {
+func (in *OvpnPKICertificateConfig) DeepCopyInto(out *OvpnPKICertificateConfig) {
 	*out = *in
 	out.OvpnCertificateConfig = in.OvpnCertificateConfig
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientCertificateConfig.
-func (in *OvpnClientCertificateConfig) DeepCopy() *OvpnClientCertificateConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPKICertificateConfig.
+func (in *OvpnPKICertificateConfig) DeepCopy() *OvpnPKICertificateConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnClientCertificateConfig)
+	out := new(OvpnPKICertificateConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnClientList) DeepCopyInto(out *OvpnClientList) {
+func (in *OvpnPKIList) DeepCopyInto(out *OvpnPKIList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]OvpnClient, len(*in))
+		*out = make([]OvpnPKI, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientList.
-func (in *OvpnClientList) DeepCopy() *OvpnClientList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPKIList.
+func (in *OvpnPKIList) DeepCopy() *OvpnPKIList {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnClientList)
+	out := new(OvpnPKIList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *OvpnClientList) DeepCopyObject() runtime.Object {
+func (in *OvpnPKIList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -116,80 +675,131 @@ func (in *OvpnClientList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnClientSpec) DeepCopyInto(out *OvpnClientSpec) {
+func (in *OvpnPKISpec) DeepCopyInto(out *OvpnPKISpec) {
 	*out = *in
-	out.Certificate = in.Certificate
+	in.OvpnPkiConfig.DeepCopyInto(&out.OvpnPkiConfig)
+	out.Server = in.Server
+	out.Clients = in.Clients
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientSpec.
-func (in *OvpnClientSpec) DeepCopy() *OvpnClientSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPKISpec.
+func (in *OvpnPKISpec) DeepCopy() *OvpnPKISpec {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnClientSpec)
+	out := new(OvpnPKISpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnClientStatus) DeepCopyInto(out *OvpnClientStatus) {
+func (in *OvpnPkiConfig) DeepCopyInto(out *OvpnPkiConfig) {
 	*out = *in
+	out.OvpnPKICertificateConfig = in.OvpnPKICertificateConfig
+	out.DN = in.DN
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(VaultIssuerReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnClientStatus.
-func (in *OvpnClientStatus) DeepCopy() *OvpnClientStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPkiConfig.
+func (in *OvpnPkiConfig) DeepCopy() *OvpnPkiConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnClientStatus)
+	out := new(OvpnPkiConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnPKICertificateConfig) DeepCopyInto(out *OvpnPKICertificateConfig) {
+func (in *OvpnPkiDnConfig) DeepCopyInto(out *OvpnPkiDnConfig) {
 	*out = *in
-	out.OvpnCertificateConfig = in.OvpnCertificateConfig
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPKICertificateConfig.
-func (in *OvpnPKICertificateConfig) DeepCopy() *OvpnPKICertificateConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPkiDnConfig.
+func (in *OvpnPkiDnConfig) DeepCopy() *OvpnPkiDnConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnPKICertificateConfig)
+	out := new(OvpnPkiDnConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnPkiConfig) DeepCopyInto(out *OvpnPkiConfig) {
+func (in *OvpnPortShare) DeepCopyInto(out *OvpnPortShare) {
 	*out = *in
-	out.OvpnPKICertificateConfig = in.OvpnPKICertificateConfig
-	out.DN = in.DN
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPkiConfig.
-func (in *OvpnPkiConfig) DeepCopy() *OvpnPkiConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPortShare.
+func (in *OvpnPortShare) DeepCopy() *OvpnPortShare {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnPkiConfig)
+	out := new(OvpnPortShare)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OvpnPkiDnConfig) DeepCopyInto(out *OvpnPkiDnConfig) {
+func (in *OvpnRoute) DeepCopyInto(out *OvpnRoute) {
 	*out = *in
+	if in.Metric != nil {
+		in, out := &in.Metric, &out.Metric
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnPkiDnConfig.
-func (in *OvpnPkiDnConfig) DeepCopy() *OvpnPkiDnConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnRoute.
+func (in *OvpnRoute) DeepCopy() *OvpnRoute {
 	if in == nil {
 		return nil
 	}
-	out := new(OvpnPkiDnConfig)
+	out := new(OvpnRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnRouteSelector) DeepCopyInto(out *OvpnRouteSelector) {
+	*out = *in
+	if in.ServiceSelector != nil {
+		in, out := &in.ServiceSelector, &out.ServiceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnRouteSelector.
+func (in *OvpnRouteSelector) DeepCopy() *OvpnRouteSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnRouteSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnSecretKeyRef) DeepCopyInto(out *OvpnSecretKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnSecretKeyRef.
+func (in *OvpnSecretKeyRef) DeepCopy() *OvpnSecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnSecretKeyRef)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -197,7 +807,7 @@ func (in *OvpnPkiDnConfig) DeepCopy() *OvpnPkiDnConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnSecurityConfig) DeepCopyInto(out *OvpnSecurityConfig) {
 	*out = *in
-	out.PKI = in.PKI
+	in.PKI.DeepCopyInto(&out.PKI)
 	out.Server = in.Server
 	out.Clients = in.Clients
 }
@@ -218,7 +828,7 @@ func (in *OvpnServer) DeepCopyInto(out *OvpnServer) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServer.
@@ -242,6 +852,28 @@ func (in *OvpnServer) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnServerAddress) DeepCopyInto(out *OvpnServerAddress) {
 	*out = *in
+	if in.Protocols != nil {
+		in, out := &in.Protocols, &out.Protocols
+		*out = make([]OvpnServerProtocol, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]OvpnServerHost, len(*in))
+		copy(*out, *in)
+	}
+	out.ServerPollTimeout = in.ServerPollTimeout
+	if in.PortShare != nil {
+		in, out := &in.PortShare, &out.PortShare
+		*out = new(OvpnPortShare)
+		**out = **in
+	}
+	in.Obfuscation.DeepCopyInto(&out.Obfuscation)
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(OvpnIPv6Config)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerAddress.
@@ -287,6 +919,11 @@ func (in *OvpnServerDeployment) DeepCopyInto(out *OvpnServerDeployment) {
 			(*out)[key] = val
 		}
 	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerDeployment.
@@ -299,6 +936,21 @@ func (in *OvpnServerDeployment) DeepCopy() *OvpnServerDeployment {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnServerHost) DeepCopyInto(out *OvpnServerHost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerHost.
+func (in *OvpnServerHost) DeepCopy() *OvpnServerHost {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnServerHost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnServerList) DeepCopyInto(out *OvpnServerList) {
 	*out = *in
@@ -331,6 +983,21 @@ func (in *OvpnServerList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnServerProtocol) DeepCopyInto(out *OvpnServerProtocol) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerProtocol.
+func (in *OvpnServerProtocol) DeepCopy() *OvpnServerProtocol {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnServerProtocol)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnServerSecrets) DeepCopyInto(out *OvpnServerSecrets) {
 	*out = *in
@@ -356,6 +1023,11 @@ func (in *OvpnServerService) DeepCopyInto(out *OvpnServerService) {
 			(*out)[key] = val
 		}
 	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(corev1.IPFamilyPolicyType)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerService.
@@ -371,9 +1043,9 @@ func (in *OvpnServerService) DeepCopy() *OvpnServerService {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnServerSpec) DeepCopyInto(out *OvpnServerSpec) {
 	*out = *in
-	out.Network = in.Network
+	in.Network.DeepCopyInto(&out.Network)
 	in.Traffic.DeepCopyInto(&out.Traffic)
-	out.Security = in.Security
+	in.Security.DeepCopyInto(&out.Security)
 	out.Secrets = in.Secrets
 	in.Deployment.DeepCopyInto(&out.Deployment)
 	in.Service.DeepCopyInto(&out.Service)
@@ -392,6 +1064,18 @@ func (in *OvpnServerSpec) DeepCopy() *OvpnServerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnServerStatus) DeepCopyInto(out *OvpnServerStatus) {
 	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnServerStatus.
@@ -404,19 +1088,55 @@ func (in *OvpnServerStatus) DeepCopy() *OvpnServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvpnTLSWrap) DeepCopyInto(out *OvpnTLSWrap) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnTLSWrap.
+func (in *OvpnTLSWrap) DeepCopy() *OvpnTLSWrap {
+	if in == nil {
+		return nil
+	}
+	out := new(OvpnTLSWrap)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvpnTrafficConfig) DeepCopyInto(out *OvpnTrafficConfig) {
 	*out = *in
+	if in.RedirectFlags != nil {
+		in, out := &in.RedirectFlags, &out.RedirectFlags
+		*out = make([]OvpnRedirectFlag, len(*in))
+		copy(*out, *in)
+	}
 	if in.Routes != nil {
 		in, out := &in.Routes, &out.Routes
+		*out = make([]OvpnRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExcludedRoutes != nil {
+		in, out := &in.ExcludedRoutes, &out.ExcludedRoutes
 		*out = make([]SubnetMask, len(*in))
 		copy(*out, *in)
 	}
+	if in.RouteSelectors != nil {
+		in, out := &in.RouteSelectors, &out.RouteSelectors
+		*out = make([]OvpnRouteSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Nameservers != nil {
 		in, out := &in.Nameservers, &out.Nameservers
-		*out = make([]IPv4Address, len(*in))
+		*out = make([]IPAddress, len(*in))
 		copy(*out, *in)
 	}
+	in.DNS.DeepCopyInto(&out.DNS)
+	out.Egress = in.Egress
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvpnTrafficConfig.
@@ -428,3 +1148,152 @@ func (in *OvpnTrafficConfig) DeepCopy() *OvpnTrafficConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultIssuer) DeepCopyInto(out *VaultIssuer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultIssuer.
+func (in *VaultIssuer) DeepCopy() *VaultIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultIssuer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultIssuerAuth) DeepCopyInto(out *VaultIssuerAuth) {
+	*out = *in
+	if in.ServiceAccountRef != nil {
+		in, out := &in.ServiceAccountRef, &out.ServiceAccountRef
+		*out = new(VaultServiceAccountReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(VaultSecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultIssuerAuth.
+func (in *VaultIssuerAuth) DeepCopy() *VaultIssuerAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultIssuerAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultIssuerList) DeepCopyInto(out *VaultIssuerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultIssuer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultIssuerList.
+func (in *VaultIssuerList) DeepCopy() *VaultIssuerList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultIssuerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultIssuerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultIssuerReference) DeepCopyInto(out *VaultIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultIssuerReference.
+func (in *VaultIssuerReference) DeepCopy() *VaultIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultIssuerSpec) DeepCopyInto(out *VaultIssuerSpec) {
+	*out = *in
+	in.Auth.DeepCopyInto(&out.Auth)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultIssuerSpec.
+func (in *VaultIssuerSpec) DeepCopy() *VaultIssuerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultIssuerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretReference) DeepCopyInto(out *VaultSecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretReference.
+func (in *VaultSecretReference) DeepCopy() *VaultSecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultServiceAccountReference) DeepCopyInto(out *VaultServiceAccountReference) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultServiceAccountReference.
+func (in *VaultServiceAccountReference) DeepCopy() *VaultServiceAccountReference {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultServiceAccountReference)
+	in.DeepCopyInto(out)
+	return out
+}
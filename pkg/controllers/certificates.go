@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/crypto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpncertificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpncertificates/status,verbs=get;update;patch
+
+const (
+	labelKeyServer = "meerkat.borchero.com/server"
+	labelKeyClient = "meerkat.borchero.com/client"
+	labelKeySerial = "meerkat.borchero.com/serial"
+)
+
+// recordIssuedCertificate creates a record for a certificate that has just been issued by the PKI
+// of the given server. The requester is the object that the certificate has been issued for. The
+// record is not owned by any object such that it survives the deletion of the server and client.
+func recordIssuedCertificate(
+	ctx context.Context, c client.Client, server *api.OvpnServer, requester metav1.Object,
+	usage api.OvpnCertificateUsage, commonName string, certificate crypto.PKICertificate,
+) error {
+	record := &api.OvpnCertificate{ObjectMeta: server.ObjectRefCertificate(certificate.Serial)}
+	_, err := ctrl.CreateOrUpdate(ctx, c, record, func() error {
+		record.Labels = map[string]string{
+			labelKeyServer: server.Name,
+			labelKeySerial: serialLabel(certificate.Serial),
+		}
+		record.Spec = api.OvpnCertificateSpec{
+			ServerName:  server.Name,
+			Usage:       usage,
+			CommonName:  commonName,
+			Serial:      certificate.Serial,
+			NotBefore:   metav1.NewTime(certificate.NotBefore),
+			NotAfter:    metav1.NewTime(certificate.Expiration),
+			RequestedBy: requester.GetAnnotations()[api.AnnotationKeyRequestedBy],
+		}
+		if usage == api.OvpnCertificateUsageClient {
+			record.Labels[labelKeyClient] = requester.GetName()
+			record.Spec.ClientName = requester.GetName()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record issued certificate: %s", err)
+	}
+	return nil
+}
+
+// recordRevokedCertificate marks all records of the certificate with the given serial as revoked.
+// Records are found via their serial label such that the revocation is recorded regardless of the
+// server whose PKI issued the certificate. Records which predate the label are looked up by name
+// for each of the given servers. Certificates which have been issued prior to keeping records are
+// ignored.
+func recordRevokedCertificate(
+	ctx context.Context, c client.Client, servers []api.OvpnServer, serial string,
+	revokedAt metav1.Time,
+) error {
+	list := &api.OvpnCertificateList{}
+	err := c.List(
+		ctx, list, client.InNamespace(servers[0].Namespace),
+		client.MatchingLabels{labelKeySerial: serialLabel(serial)},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list certificate records: %s", err)
+	}
+	records := list.Items
+	if len(records) == 0 {
+		for i := range servers {
+			record := api.OvpnCertificate{ObjectMeta: servers[i].ObjectRefCertificate(serial)}
+			err := c.Get(ctx, client.ObjectKeyFromObject(&record), &record)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get certificate record: %s", err)
+			}
+			records = append(records, record)
+		}
+	}
+
+	for i := range records {
+		if records[i].Status.RevokedAt != nil {
+			continue
+		}
+		records[i].Status.RevokedAt = &revokedAt
+		if err := c.Status().Update(ctx, &records[i]); err != nil {
+			return fmt.Errorf("failed to record certificate revocation: %s", err)
+		}
+	}
+	return nil
+}
+
+// serialLabel returns the value of the serial label for the given serial. Colons are not allowed
+// in label values.
+func serialLabel(serial string) string {
+	return strings.ReplaceAll(serial, ":", "")
+}
@@ -3,43 +3,56 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 	"github.com/borchero/meerkat-operator/pkg/crypto"
 	"github.com/borchero/meerkat-operator/pkg/ovpn"
-	vaultapi "github.com/hashicorp/vault/api"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnclients,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnclients/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // OvpnClientReconciler reconciles OvpnClient objects.
 type OvpnClientReconciler struct {
 	ctclient.Client
-	config Config
-	vault  *vaultapi.Client
-	scheme *runtime.Scheme
-	logger *zap.Logger
+	config   Config
+	vaults   *VaultProvider
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	logger   *zap.Logger
 }
 
 // MustSetupOvpnClientReconciler initializes a new server reconciler and attaches it to the given
 // manager. It panics on failure.
 func MustSetupOvpnClientReconciler(
-	config Config, vault *vaultapi.Client, mgr ctrl.Manager, logger *zap.Logger,
+	config Config, vaults *VaultProvider, mgr ctrl.Manager, logger *zap.Logger,
 ) {
 	reconciler := &OvpnClientReconciler{
-		Client: mgr.GetClient(),
-		config: config,
-		vault:  vault,
-		scheme: mgr.GetScheme(),
-		logger: logger,
+		Client:   mgr.GetClient(),
+		config:   config,
+		vaults:   vaults,
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("ovpnclient-controller"),
+		logger:   logger,
 	}
 	if err := reconciler.setupWithManager(mgr); err != nil {
 		panic(err)
@@ -50,13 +63,40 @@ func (r *OvpnClientReconciler) setupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&api.OvpnClient{}).
 		Owns(&corev1.Secret{}).
+		Watches(
+			&source.Kind{Type: &api.OvpnServer{}},
+			handler.EnqueueRequestsFromMapFunc(r.clientRequestsForServer),
+		).
 		Complete(r)
 }
 
+func (r *OvpnClientReconciler) clientRequestsForServer(obj ctclient.Object) []reconcile.Request {
+	// Changes to the server may require the profiles of its clients to be re-rendered
+	list := &api.OvpnClientList{}
+	err := r.List(context.Background(), list, ctclient.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		r.logger.Error("failed to list clients of server", zap.Error(err))
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, item := range list.Items {
+		if item.IsAssociatedWith(obj.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+			})
+		}
+	}
+	return requests
+}
+
 //-------------------------------------------------------------------------------------------------
 
 const (
 	secretKeyOvpnCertificate = "certificate.ovpn"
+	secretKeyOvpnTLSWrapped  = "certificate-tls.ovpn"
+	secretKeyStunnelConfig   = "stunnel.conf"
+	secretKeyClientCrt       = "client.crt"
+	secretKeyClientKey       = "client.key"
 
 	annotationKeySerial = "meerkat.borchero.com/serial"
 	annotationKeyDirty  = "meerkat.borchero.com/dirty"
@@ -78,13 +118,13 @@ func (r *OvpnClientReconciler) Reconcile(
 
 	// If the client currently exists, we need to check for deletion
 	if !client.DeletionTimestamp.IsZero() {
-		// In that case, we need to revoke the certificate if the finalizer still exists
+		// In that case, we need to revoke all certificates if the finalizer still exists
 		if controllerutil.ContainsFinalizer(client, finalizerIdentifier) {
-			if err := r.revokeCertificate(ctx, client, logger); err != nil {
-				logger.Error("failed to revoke certificate", zap.Error(err))
+			if _, err := r.revokeCertificates(ctx, client, logger); err != nil {
+				logger.Error("failed to revoke certificates", zap.Error(err))
 				return ctrl.Result{}, err
 			}
-			logger.Debug("successfully revoked certificate")
+			logger.Debug("successfully revoked certificates")
 		}
 		controllerutil.RemoveFinalizer(client, finalizerIdentifier)
 		if err := r.Update(ctx, client); err != nil {
@@ -104,49 +144,210 @@ func (r *OvpnClientReconciler) Reconcile(
 		}
 	}
 
+	// If the client's access is time-boxed, we need to check whether it has expired already
+	deadline, expires := client.AccessDeadline()
+	if expires && !deadline.After(time.Now()) {
+		if err := r.expireClient(ctx, client, logger); err != nil {
+			logger.Error("failed to expire client", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+		logger.Info("reconciliation of expired client succeeded")
+		return ctrl.Result{}, nil
+	}
+
 	// Then, we can create the client's certificate
-	if err := r.updateCertificate(ctx, client); err != nil {
+	if err := r.updateCertificate(ctx, client, logger); err != nil {
 		logger.Error("failed to reconcile certificate", zap.Error(err))
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("reconciliation succeeded")
+	if expires {
+		// Make sure that we revisit the client once its access expires
+		return ctrl.Result{RequeueAfter: time.Until(deadline)}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
 //-------------------------------------------------------------------------------------------------
 
-func (r *OvpnClientReconciler) revokeCertificate(
+func (r *OvpnClientReconciler) expireClient(
 	ctx context.Context, client *api.OvpnClient, logger *zap.Logger,
 ) error {
-	// First, we need to get the certificate secret
+	// If the client should be deleted, the finalizer takes care of revoking the certificates
+	if client.Spec.DeleteOnExpiry {
+		if err := r.Delete(ctx, client); err != nil {
+			return fmt.Errorf("failed to delete expired client: %s", err)
+		}
+		r.recorder.Event(
+			client, corev1.EventTypeNormal, "Expired", "Access expired, deleting client",
+		)
+		return nil
+	}
+
+	// Otherwise, we revoke all certificates which are still valid...
+	revoked, err := r.revokeCertificates(ctx, client, logger)
+	if err != nil {
+		return fmt.Errorf("failed to revoke certificates: %s", err)
+	}
+	if revoked > 0 {
+		if err := r.Status().Update(ctx, client); err != nil {
+			return fmt.Errorf("failed to update client status: %s", err)
+		}
+		r.recorder.Event(
+			client, corev1.EventTypeNormal, "Expired", "Access expired, revoked client certificate",
+		)
+	}
+
+	// ... and remove the now useless profile
+	secret := &corev1.Secret{ObjectMeta: client.ObjectRefCertificateSecret()}
+	if err := r.Delete(ctx, secret); ctclient.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete certificate secret: %s", err)
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (r *OvpnClientReconciler) revokeCertificates(
+	ctx context.Context, client *api.OvpnClient, logger *zap.Logger,
+) (int, error) {
+	// First, we need to find all serials which still need to be revoked
+	serials, err := r.getRevocableSerials(ctx, client, logger)
+	if err != nil {
+		return 0, err
+	}
+	if len(serials) == 0 {
+		return 0, nil
+	}
+
+	// Then, we fetch the associated servers to get the correct PKI. If none of them can be found,
+	// we don't need to revoke anything so we're done.
+	servers, err := r.getServers(ctx, client, true)
+	if err != nil {
+		return 0, err
+	}
+	if len(servers) == 0 {
+		return 0, nil
+	}
+	server := &servers[0]
+
+	// Then, we revoke the certificates with the serials from above using the PKIs that issued
+	// them. Revoked certificates are marked in the client's status but it is up to the caller to
+	// persist it.
+	now := metav1.Now()
+	for _, serial := range serials {
+		pki, err := r.getIssuingPKI(ctx, server, serial)
+		if err != nil {
+			return 0, err
+		}
+		if err := pki.Revoke(serial); err != nil {
+			return 0, fmt.Errorf("failed to revoke certificate: %s", err)
+		}
+		if err := recordRevokedCertificate(ctx, r, servers, serial, now); err != nil {
+			return 0, err
+		}
+		for i := range client.Status.Certificates {
+			if client.Status.Certificates[i].Serial == serial {
+				client.Status.Certificates[i].RevokedAt = &now
+			}
+		}
+		logger.Debug("revoked certificate", zap.String("serial", serial))
+	}
+
+	// After doing so, we need to trigger an update of the CRLs of all servers. We simply trigger
+	// a reconciliation of the servers by adding an annotation to their secrets.
+	for i := range servers {
+		crl := &corev1.Secret{ObjectMeta: servers[i].ObjectRefCrlSecret()}
+		op, err := ctrl.CreateOrUpdate(ctx, r, crl, func() error {
+			crl.Annotations = map[string]string{
+				annotationKeyDirty: "true",
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to flag CRL secret as dirty: %s", err)
+		}
+		logger.Debug(
+			"flagged CRL as dirty",
+			zap.String("server", servers[i].Name), zap.String("operation", string(op)),
+		)
+	}
+	return len(serials), nil
+}
+
+// getIssuingPKI returns the PKI that issued the certificate with the given serial. It is the PKI
+// of the server found in the certificate's record which may differ from the client's current
+// servers. If the record or its server cannot be found, the PKI of the given server is returned.
+func (r *OvpnClientReconciler) getIssuingPKI(
+	ctx context.Context, server *api.OvpnServer, serial string,
+) (*crypto.PKI, error) {
+	list := &api.OvpnCertificateList{}
+	err := r.List(
+		ctx, list, ctclient.InNamespace(server.Namespace),
+		ctclient.MatchingLabels{labelKeySerial: serialLabel(serial)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list certificate records: %s", err)
+	}
+	for _, record := range list.Items {
+		if record.Spec.ServerName == server.Name {
+			break
+		}
+		issuer := &api.OvpnServer{}
+		issuerRef := ctclient.ObjectKey{Name: record.Spec.ServerName, Namespace: server.Namespace}
+		if err := r.Get(ctx, issuerRef, issuer); err != nil {
+			if apierrors.IsNotFound(err) {
+				break
+			}
+			return nil, fmt.Errorf("failed to get server that issued certificate: %s", err)
+		}
+		return r.vaults.PKI(ctx, issuer)
+	}
+	return r.vaults.PKI(ctx, server)
+}
+
+func (r *OvpnClientReconciler) getRevocableSerials(
+	ctx context.Context, client *api.OvpnClient, logger *zap.Logger,
+) ([]string, error) {
+	// All certificates issued for the client are tracked in its status and need to be revoked
+	// unless they already are or have expired
+	serials := []string{}
+	for _, certificate := range client.Status.Certificates {
+		if certificate.RevokedAt == nil && certificate.ExpiresAt.After(time.Now()) {
+			serials = append(serials, certificate.Serial)
+		}
+	}
+
+	// Additionally, certificates issued prior to tracking them in the status are only known from
+	// the certificate secret
 	secret := &corev1.Secret{ObjectMeta: client.ObjectRefCertificateSecret()}
 	err := r.Get(ctx, ctclient.ObjectKeyFromObject(secret), secret)
 	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to check for certificate secret: %s", err)
+		return nil, fmt.Errorf("failed to check for certificate secret: %s", err)
 	}
 
 	if apierrors.IsNotFound(err) {
 		// If the certificate secret does not exist, we can simply return
-		return nil
+		return serials, nil
 	}
 
 	// If the secret does exist, we parse the expiration date
 	expirationString, ok := secret.Annotations[annotationKeyExpiresAt]
 	if !ok {
 		// We can't know if the secret is expired, we don't do anything
-		return nil
+		return serials, nil
 	}
 	expiration, err := time.Parse(time.RFC3339, expirationString)
 	if err != nil {
 		// We don't know about expiration again
 		logger.Warn("revocation skipped due to missing expiration date")
-		return nil
+		return serials, nil
 	}
 	if expiration.Before(time.Now()) {
 		// If the expiration is in the past, we can return
 		logger.Warn("revocation skipped due to invalid expiration date")
-		return nil
+		return serials, nil
 	}
 
 	// If the certificate has not expired yet, we need to revoke it. For that, it is required that
@@ -155,46 +356,18 @@ func (r *OvpnClientReconciler) revokeCertificate(
 	if !ok {
 		// We will never be able to revoke the certificate, so we just return
 		logger.Warn("revocation skipped due to missing serial")
-		return nil
-	}
-
-	// Then, we fetch the associated server to get the correct PKI
-	server := &api.OvpnServer{}
-	serverRef := ctclient.ObjectKey{Name: client.Spec.ServerName, Namespace: client.Namespace}
-	if err := r.Get(ctx, serverRef, server); err != nil {
-		if apierrors.IsNotFound(err) {
-			// If the server cannot be found, we don't need to revoke anything so we're done
-			return nil
-		}
-		return fmt.Errorf("failed to get server associated with client: %s", err)
+		return serials, nil
 	}
-
-	// Then, we get the PKI and revoke the certificate with the serial from above
-	pki := r.getPKI(server)
-	if err := pki.Revoke(serial); err != nil {
-		return fmt.Errorf("failed to revoke certificate: %s", err)
+	if !client.HasIssuedCertificate(serial) {
+		serials = append(serials, serial)
 	}
-
-	// After doing so, we need to trigger an update of the CRL. We simply trigger a reconciliation
-	// of the server by adding an annotation to the secret.
-	crl := &corev1.Secret{ObjectMeta: server.ObjectRefCrlSecret()}
-	op, err := ctrl.CreateOrUpdate(ctx, r, crl, func() error {
-		secret.Annotations = map[string]string{
-			annotationKeyDirty: "true",
-		}
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("failed to flag CRL secret as dirty: %s", err)
-	}
-	logger.Debug("flagged CRL as dirty", zap.String("operation", string(op)))
-	return nil
+	return serials, nil
 }
 
 //-------------------------------------------------------------------------------------------------
 
 func (r *OvpnClientReconciler) updateCertificate(
-	ctx context.Context, client *api.OvpnClient,
+	ctx context.Context, client *api.OvpnClient, logger *zap.Logger,
 ) error {
 	// First, we get the certificate secret
 	secret := &corev1.Secret{ObjectMeta: client.ObjectRefCertificateSecret()}
@@ -202,51 +375,330 @@ func (r *OvpnClientReconciler) updateCertificate(
 	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("failed to check for certificate secret: %s", err)
 	}
-	if err == nil {
-		// If the certificate already exists, we don't do anything. Specifially, we don't
-		// automatically renew certificates.
-		return nil
+	exists := err == nil
+	reissue := client.Annotations[api.AnnotationKeyReissue]
+	if exists {
+		// If the certificate already exists, we only keep its profiles up-to-date unless a
+		// reissue has been requested explicitly. Specifially, we don't automatically renew
+		// certificates.
+		// The certificate must also be reissued if the client's servers are now backed by a
+		// different PKI which would reject it.
+		if reissue == "" || reissue == client.Status.ObservedReissue {
+			changed, err := r.hasChangedPKI(ctx, client, secret)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				return r.updateProfiles(ctx, client, secret, logger)
+			}
+			logger.Info("reissuing certificate as the client's PKI changed")
+		} else {
+			logger.Info("reissuing certificate", zap.String("reissue", reissue))
+		}
+	}
+
+	// Prior to issuing a new certificate, all previously issued certificates must be revoked. This
+	// ensures that certificates are revoked even if their secret has been deleted manually.
+	if _, err := r.revokeCertificates(ctx, client, logger); err != nil {
+		return fmt.Errorf("failed to revoke previous certificates: %s", err)
 	}
 
-	// If we cannot find it, we create the certificate. For that, we first need to find the server
-	// which is responsible for the user.
-	server := &api.OvpnServer{}
-	serverRef := ctclient.ObjectKey{Name: client.Spec.ServerName, Namespace: client.Namespace}
-	if err := r.Get(ctx, serverRef, server); err != nil {
-		return fmt.Errorf("failed to get server associated with client: %s", err)
+	// If we cannot find it or need to reissue it, we create the certificate. For that, we first
+	// need to find the servers which are responsible for the user. A single certificate can only
+	// be used for multiple servers if they share their PKI.
+	servers, err := r.getServers(ctx, client, false)
+	if err != nil {
+		return err
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("client is not associated with any server")
 	}
+	if err := checkSharedPKI(servers); err != nil {
+		return err
+	}
+	server := &servers[0]
 
 	// Then, we can get the correct PKI.
-	pki := r.getPKI(server)
+	pki, err := r.vaults.PKI(ctx, server)
+	if err != nil {
+		return err
+	}
 
 	// Afterwards, we can generate the private key and certificate.
 	validity := client.Spec.Certificate.Validity.Duration
 	if validity == 0 {
-		validity = server.Spec.Security.Clients.DefaultedValidity()
+		validity, err = r.getDefaultValidity(ctx, server)
+		if err != nil {
+			return err
+		}
+	}
+	if deadline, ok := client.AccessDeadline(); ok {
+		// The certificate must never outlive the client's access
+		if remaining := time.Until(deadline); remaining < validity {
+			validity = remaining
+		}
 	}
 	certificate, err := pki.Generate("client", client.Spec.CommonName, validity)
 	if err != nil {
 		return fmt.Errorf("failed to generate new certificate: %s", err)
 	}
+	if err := recordIssuedCertificate(
+		ctx, r, server, client, api.OvpnCertificateUsageClient, client.Spec.CommonName, certificate,
+	); err != nil {
+		return err
+	}
 
-	// With the certificate, we can now load the shared TLSAuth parameter and then write the
-	// full OVPN certificate.
+	// With the certificate, we can render the profiles
+	profiles, err := r.renderProfiles(ctx, client, servers, certificate)
+	if err != nil {
+		return err
+	}
+
+	// And finally, we can store the certificate in the previously referenced secret
+	secret.Annotations = map[string]string{
+		annotationKeyExpiresAt: certificate.Expiration.Format(time.RFC3339),
+		annotationKeySerial:    certificate.Serial,
+	}
+	secret.Data = profileSecretData(profiles, certificate)
+	if err := ctrl.SetControllerReference(client, secret, r.scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference on certificate secret: %s", err)
+	}
+	if exists {
+		if err := r.Update(ctx, secret); err != nil {
+			return fmt.Errorf("failed to update secret containing certificate: %s", err)
+		}
+	} else {
+		if err := r.Create(ctx, secret); err != nil {
+			return fmt.Errorf("failed to create secret containing certificate: %s", err)
+		}
+	}
+
+	// Eventually, we record the issuance in the client's status such that reissues are only
+	// performed once
+	client.Status.ObservedReissue = reissue
+	issued := api.OvpnIssuedCertificate{
+		Serial:    certificate.Serial,
+		IssuedAt:  metav1.Now(),
+		ExpiresAt: metav1.NewTime(certificate.Expiration),
+	}
+	client.Status.Certificates = append(client.UnexpiredCertificates(), issued)
+	if exists {
+		now := metav1.Now()
+		client.Status.LastRotation = &now
+	}
+	if err := r.Status().Update(ctx, client); err != nil {
+		return fmt.Errorf("failed to update client status: %s", err)
+	}
+	if exists {
+		r.recorder.Event(client, corev1.EventTypeNormal, "Reissued", "Reissued client certificate")
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (r *OvpnClientReconciler) getServers(
+	ctx context.Context, client *api.OvpnClient, ignoreMissing bool,
+) ([]api.OvpnServer, error) {
+	servers := []api.OvpnServer{}
+	for _, name := range client.AllServerNames() {
+		server := api.OvpnServer{}
+		serverRef := ctclient.ObjectKey{Name: name, Namespace: client.Namespace}
+		if err := r.Get(ctx, serverRef, &server); err != nil {
+			if ignoreMissing && apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get server associated with client: %s", err)
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+func (r *OvpnClientReconciler) getDefaultValidity(
+	ctx context.Context, server *api.OvpnServer,
+) (time.Duration, error) {
+	if server.Spec.Security.PKIName == "" {
+		return server.Spec.Security.Clients.DefaultedValidity(), nil
+	}
+	shared := &api.OvpnPKI{}
+	sharedRef := ctclient.ObjectKey{Name: server.Spec.Security.PKIName, Namespace: server.Namespace}
+	if err := r.Get(ctx, sharedRef, shared); err != nil {
+		return 0, fmt.Errorf("failed to get shared PKI: %s", err)
+	}
+	return shared.Spec.Clients.DefaultedValidity(), nil
+}
+
+// hasChangedPKI returns whether the certificate stored in the given secret has been issued by a
+// different PKI than the one of the client's servers. Secrets created by earlier versions do not
+// store the CA and are never considered changed.
+func (r *OvpnClientReconciler) hasChangedPKI(
+	ctx context.Context, client *api.OvpnClient, secret *corev1.Secret,
+) (bool, error) {
+	issuingCA := strings.TrimSpace(string(secret.Data[secretKeyCaCrt]))
+	if issuingCA == "" {
+		return false, nil
+	}
+	servers, err := r.getServers(ctx, client, false)
+	if err != nil {
+		return false, err
+	}
+	if len(servers) == 0 || checkSharedPKI(servers) != nil {
+		// Rendering the profiles fails for these clients anyway
+		return false, nil
+	}
+	pki, err := r.vaults.PKI(ctx, &servers[0])
+	if err != nil {
+		return false, err
+	}
+	currentCA, err := pki.CACertificate()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(currentCA) != issuingCA, nil
+}
+
+func (r *OvpnClientReconciler) updateProfiles(
+	ctx context.Context, client *api.OvpnClient, secret *corev1.Secret, logger *zap.Logger,
+) error {
+	// Profiles can only be re-rendered if the certificate is stored alongside them which is not
+	// the case for secrets created by earlier versions
+	certificate := crypto.PKICertificate{
+		Certificate:   string(secret.Data[secretKeyClientCrt]),
+		PrivateKey:    string(secret.Data[secretKeyClientKey]),
+		CACertificate: string(secret.Data[secretKeyCaCrt]),
+	}
+	if certificate.Certificate == "" || certificate.PrivateKey == "" ||
+		certificate.CACertificate == "" {
+		return nil
+	}
+
+	// Then, we render the profiles for the current state of the servers...
+	servers, err := r.getServers(ctx, client, false)
+	if err != nil {
+		return err
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("client is not associated with any server")
+	}
+	if err := checkSharedPKI(servers); err != nil {
+		return err
+	}
+	profiles, err := r.renderProfiles(ctx, client, servers, certificate)
+	if err != nil {
+		return err
+	}
+
+	// ... and update the secret if any of them changed
+	data := profileSecretData(profiles, certificate)
+	if equality.Semantic.DeepEqual(data, secret.Data) {
+		return nil
+	}
+	secret.Data = data
+	if err := r.Update(ctx, secret); err != nil {
+		return fmt.Errorf("failed to update secret containing certificate: %s", err)
+	}
+	logger.Info("updated client profiles")
+	return nil
+}
+
+// renderProfiles renders the profiles of the client for the given certificate. Either all servers
+// are combined into a single profile or each one gets its own. Servers which wrap their transport
+// in TLS additionally get profiles connecting via a local stunnel client whose config is rendered
+// alongside.
+func (r *OvpnClientReconciler) renderProfiles(
+	ctx context.Context, client *api.OvpnClient, servers []api.OvpnServer,
+	certificate crypto.PKICertificate,
+) (map[string]string, error) {
+	// First, we assign a local port to each server that is reached via stunnel
+	tunnels := ovpn.StunnelValues{Client: true}
+	localPorts := map[string]uint16{}
+	for _, server := range servers {
+		wrap := server.Spec.Network.Obfuscation.TLSWrap
+		if wrap == nil {
+			continue
+		}
+		localPort := uint16(1194 + len(localPorts))
+		localPorts[server.Name] = localPort
+		connect := []string{}
+		port := strconv.Itoa(int(wrap.DefaultedPort()))
+		for _, host := range append([]string{server.PrimaryHost()}, server.AlternativeNames()...) {
+			connect = append(connect, net.JoinHostPort(host, port))
+		}
+		tunnels.Services = append(tunnels.Services, ovpn.StunnelService{
+			Name:    server.Name,
+			Accept:  fmt.Sprintf("127.0.0.1:%d", localPort),
+			Connect: connect,
+			CAFile:  secretKeyCaCrt,
+		})
+	}
+
+	// Then, we render the profiles
+	profiles := map[string]string{}
+	if client.Spec.DefaultedProfileMode() == api.OvpnProfileModePerServer {
+		for i := range servers {
+			profile, err := r.renderProfile(ctx, servers[i:i+1], certificate, nil)
+			if err != nil {
+				return nil, err
+			}
+			profiles[fmt.Sprintf("%s.ovpn", servers[i].Name)] = profile
+			if _, ok := localPorts[servers[i].Name]; !ok {
+				continue
+			}
+			profile, err = r.renderProfile(ctx, servers[i:i+1], certificate, localPorts)
+			if err != nil {
+				return nil, err
+			}
+			profiles[fmt.Sprintf("%s-tls.ovpn", servers[i].Name)] = profile
+		}
+	} else {
+		profile, err := r.renderProfile(ctx, servers, certificate, nil)
+		if err != nil {
+			return nil, err
+		}
+		profiles[secretKeyOvpnCertificate] = profile
+		if len(localPorts) > 0 {
+			profile, err := r.renderProfile(ctx, servers, certificate, localPorts)
+			if err != nil {
+				return nil, err
+			}
+			profiles[secretKeyOvpnTLSWrapped] = profile
+		}
+	}
+
+	// Eventually, we add the stunnel config if it is required by any profile
+	if len(localPorts) > 0 {
+		config, err := ovpn.GetStunnelConfig(tunnels)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render stunnel config: %s", err)
+		}
+		profiles[secretKeyStunnelConfig] = config
+	}
+	return profiles, nil
+}
+
+// renderProfile renders a profile which allows to connect to all of the given servers. The TLS
+// auth and security settings are taken from the first server. If local ports are given, the
+// profile only connects to the servers listed there, via the local stunnel client.
+func (r *OvpnClientReconciler) renderProfile(
+	ctx context.Context, servers []api.OvpnServer, certificate crypto.PKICertificate,
+	localPorts map[string]uint16,
+) (string, error) {
+	// First, we load the shared TLSAuth parameter
+	server := &servers[0]
 	sharedSecret := &corev1.Secret{ObjectMeta: server.ObjectRefSharedSecrets()}
 	if err := r.Get(ctx, ctclient.ObjectKeyFromObject(sharedSecret), sharedSecret); err != nil {
-		return fmt.Errorf("failed to get shared secret to build OVPN certificate: %s", err)
+		return "", fmt.Errorf("failed to get shared secret to build OVPN certificate: %s", err)
 	}
-
-	// Get the TLS auth parameters
 	tlsAuth, ok := sharedSecret.Data[secretKeyTa]
 	if !ok {
-		return fmt.Errorf("shared secret does not contain TLS auth")
+		return "", fmt.Errorf("shared secret does not contain TLS auth")
 	}
 
-	// Render the file
+	// Then, we can render the file
 	values := ovpn.CertificateValues{
-		Host:     server.Spec.Network.Host,
-		Port:     server.Spec.Service.DefaultedPort(),
-		Protocol: string(server.Spec.Network.DefaultedProtocol()),
+		RemoteRandom:      server.Spec.Network.RemoteRandom,
+		ServerPollTimeout: int(server.Spec.Network.ServerPollTimeout.Seconds()),
 		Security: ovpn.ConfigSecurity{
 			Hmac:   string(server.Spec.Security.DefaultedHmac()),
 			Cipher: string(server.Spec.Security.DefaultedCipher()),
@@ -258,32 +710,55 @@ func (r *OvpnClientReconciler) updateCertificate(
 			TLSAuth:      string(tlsAuth),
 		},
 	}
-	ovpnCert, err := ovpn.GetCertificate(values)
+	for _, server := range servers {
+		if localPorts != nil {
+			if localPort, ok := localPorts[server.Name]; ok {
+				values.Remotes = append(values.Remotes, ovpn.CertificateRemote{
+					Host:     "127.0.0.1",
+					Port:     localPort,
+					Protocol: string(corev1.ProtocolTCP),
+				})
+			}
+			continue
+		}
+		for _, host := range server.Remotes() {
+			values.Remotes = append(values.Remotes, ovpn.CertificateRemote{
+				Host:     host.Host,
+				Port:     host.Port,
+				Protocol: string(host.Protocol),
+			})
+		}
+	}
+	profile, err := ovpn.GetCertificate(values)
 	if err != nil {
-		return fmt.Errorf("failed to render OVPN certificate: %s", err)
+		return "", fmt.Errorf("failed to render OVPN certificate: %s", err)
 	}
+	return profile, nil
+}
 
-	// And finally, we can store the certificate in the previously referenced secret
-	secret.Annotations = map[string]string{
-		annotationKeyExpiresAt: certificate.Expiration.Format(time.RFC3339),
-		annotationKeySerial:    certificate.Serial,
-	}
-	secret.StringData = map[string]string{
-		secretKeyOvpnCertificate: ovpnCert,
-	}
-	if err := ctrl.SetControllerReference(client, secret, r.scheme); err != nil {
-		return fmt.Errorf("failed to set owner reference on certificate secret: %s", err)
-	}
-	if err := r.Create(ctx, secret); err != nil {
-		return fmt.Errorf("failed to create secret containing certificate: %s", err)
+// checkSharedPKI ensures that a single certificate can be used for all of the given servers.
+func checkSharedPKI(servers []api.OvpnServer) error {
+	for _, other := range servers[1:] {
+		pkiName := other.Spec.Security.PKIName
+		if pkiName == "" || pkiName != servers[0].Spec.Security.PKIName {
+			return fmt.Errorf("servers associated with client do not share a PKI")
+		}
 	}
 	return nil
 }
 
-//-------------------------------------------------------------------------------------------------
-
-func (r *OvpnClientReconciler) getPKI(server *api.OvpnServer) *crypto.PKI {
-	return crypto.NewPKI(
-		r.vault, fmt.Sprintf("%s/%s/%s", r.config.PKIPath, server.Namespace, server.Name),
-	)
+// profileSecretData returns the contents of a client's certificate secret. Next to the profiles,
+// it contains the certificate itself such that profiles can be re-rendered when servers change.
+func profileSecretData(
+	profiles map[string]string, certificate crypto.PKICertificate,
+) map[string][]byte {
+	data := map[string][]byte{
+		secretKeyClientCrt: []byte(certificate.Certificate),
+		secretKeyClientKey: []byte(certificate.PrivateKey),
+		secretKeyCaCrt:     []byte(certificate.CACertificate),
+	}
+	for key, profile := range profiles {
+		data[key] = []byte(profile)
+	}
+	return data
 }
@@ -0,0 +1,456 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/controllers/ovpnconnector"
+	"github.com/borchero/meerkat-operator/pkg/supervisor"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnconnectors,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnconnectors/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+// OvpnConnectorReconciler reconciles OvpnConnector objects.
+type OvpnConnectorReconciler struct {
+	client.Client
+	config Config
+	scheme *runtime.Scheme
+	http   *http.Client
+	logger *zap.Logger
+}
+
+// MustSetupOvpnConnectorReconciler initializes a new connector reconciler and attaches it to the
+// given manager. It panics on failure.
+func MustSetupOvpnConnectorReconciler(config Config, mgr ctrl.Manager, logger *zap.Logger) {
+	reconciler := &OvpnConnectorReconciler{
+		Client: mgr.GetClient(),
+		config: config,
+		scheme: mgr.GetScheme(),
+		http:   &http.Client{Timeout: 5 * time.Second},
+		logger: logger,
+	}
+	if err := reconciler.setupWithManager(mgr); err != nil {
+		panic(err)
+	}
+}
+
+func (r *OvpnConnectorReconciler) setupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.OvpnConnector{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.connectorRequestsForProfile),
+		).
+		Watches(
+			&source.Kind{Type: &api.OvpnClient{}},
+			handler.EnqueueRequestsFromMapFunc(r.connectorRequestsForProfile),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(r.connectorRequestsForPod),
+		).
+		Complete(r)
+}
+
+// connectorRequestsForProfile returns requests for all connectors whose profile is provided by
+// the given secret or client.
+func (r *OvpnConnectorReconciler) connectorRequestsForProfile(
+	obj client.Object,
+) []reconcile.Request {
+	list := &api.OvpnConnectorList{}
+	err := r.List(context.Background(), list, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		r.logger.Error("failed to list connectors", zap.Error(err))
+		return nil
+	}
+
+	// Secrets of clients are controlled by the clients
+	clientName := ""
+	if _, ok := obj.(*api.OvpnClient); ok {
+		clientName = obj.GetName()
+	} else if owner := metav1.GetControllerOf(obj); owner != nil && owner.Kind == "OvpnClient" {
+		clientName = owner.Name
+	}
+
+	requests := []reconcile.Request{}
+	for _, connector := range list.Items {
+		profile := connector.Spec.Profile
+		_, isSecret := obj.(*corev1.Secret)
+		if (isSecret && profile.SecretRef != nil && profile.SecretRef.Name == obj.GetName()) ||
+			(clientName != "" && profile.ClientName == clientName) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: connector.Namespace, Name: connector.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+// connectorRequestsForPod returns requests for all connectors that the given pod is running.
+func (r *OvpnConnectorReconciler) connectorRequestsForPod(obj client.Object) []reconcile.Request {
+	list := &api.OvpnConnectorList{}
+	err := r.List(context.Background(), list, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		r.logger.Error("failed to list connectors", zap.Error(err))
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, connector := range list.Items {
+		selector := labels.SelectorFromSet(ovpnconnector.GetSelectorLabels(&connector))
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: connector.Namespace, Name: connector.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+//-------------------------------------------------------------------------------------------------
+
+const (
+	annotationKeyProfileHash = "meerkat.borchero.com/profile-hash"
+
+	// The period after which querying the tunnel IP of a connector is retried.
+	tunnelIPRetryPeriod = 30 * time.Second
+)
+
+// Reconcile reconciles the given request.
+func (r *OvpnConnectorReconciler) Reconcile(
+	ctx context.Context, req ctrl.Request,
+) (ctrl.Result, error) {
+	logger := r.logger.With(zap.String("name", req.String()))
+	logger.Debug("starting reconciliation")
+
+	// First, we get the connector - if it cannot be found, it has been deleted along with all of
+	// its dependents
+	connector := &api.OvpnConnector{}
+	if err := r.Get(ctx, req.NamespacedName, connector); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Before reconciling, we make sure that the configuration is consistent. Invalid connectors
+	// are not requeued as they need to be changed to become valid.
+	if err := ovpnconnector.Validate(connector); err != nil {
+		logger.Error("connector configuration is invalid", zap.Error(err))
+		return ctrl.Result{}, nil
+	}
+
+	// Then, we need the profile. If it is unavailable, we wait for the secret or client to change.
+	profile, reason, err := r.getProfile(ctx, connector)
+	if err != nil {
+		logger.Error("failed to get profile", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	if reason != "" {
+		logger.Info("waiting for profile to become available", zap.String("reason", reason))
+		err := r.updateStatus(ctx, connector, api.OvpnConnectorStatePending, "", reason)
+		if err != nil {
+			logger.Error("failed to update status", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Afterwards, we can update the config, deployment and service. Pods are restarted whenever
+	// the config or the profile change.
+	logger.Debug("reconciling k8s resources")
+	configHash, err := r.updateConfigMap(ctx, connector, logger)
+	if err != nil {
+		logger.Error("failed to reconcile configmap", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	podAnnotations := map[string]string{
+		annotationKeyConfigHash:  configHash,
+		annotationKeyProfileHash: profile.hash,
+	}
+	if err := r.updateDeployment(ctx, connector, profile, podAnnotations, logger); err != nil {
+		logger.Error("failed to reconcile deployment", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	if err := r.updateService(ctx, connector, logger); err != nil {
+		logger.Error("failed to reconcile service", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+
+	// Eventually, we record the state of the tunnel. The connector's pod is only ready while its
+	// tunnel is established and changes of the pod trigger a reconciliation. Only then, the pod
+	// is asked for its address in the remote VPN.
+	state, pod, message, err := r.getConnectorState(ctx, connector)
+	if err != nil {
+		logger.Error("failed to get connector state", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	result := ctrl.Result{}
+	tunnelIP := ""
+	if state == api.OvpnConnectorStateConnected {
+		tunnelIP, err = r.getTunnelIP(ctx, pod)
+		if err != nil {
+			// The previous address is kept until the pod can be queried again
+			logger.Warn("failed to get tunnel IP", zap.Error(err))
+			tunnelIP = connector.Status.TunnelIP
+			result.RequeueAfter = tunnelIPRetryPeriod
+		}
+	}
+	if err := r.updateStatus(ctx, connector, state, tunnelIP, message); err != nil {
+		logger.Error("failed to update status", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+
+	logger.Debug("reconciliation succeeded", zap.String("state", string(state)))
+	return result, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// connectorProfile references the profile of a connector.
+type connectorProfile struct {
+	secretName string
+	key        string
+	hash       string
+}
+
+// getProfile finds the profile of the given connector. If the profile is not available, the
+// reason is returned instead.
+func (r *OvpnConnectorReconciler) getProfile(
+	ctx context.Context, connector *api.OvpnConnector,
+) (connectorProfile, string, error) {
+	// First, we find the secret and the key of the profile...
+	profile := connectorProfile{}
+	if ref := connector.Spec.Profile.SecretRef; ref != nil {
+		profile.secretName = ref.Name
+		profile.key = ref.DefaultedKey()
+	} else {
+		ovpnClient := &api.OvpnClient{}
+		key := client.ObjectKey{
+			Namespace: connector.Namespace, Name: connector.Spec.Profile.ClientName,
+		}
+		if err := r.Get(ctx, key, ovpnClient); err != nil {
+			if apierrors.IsNotFound(err) {
+				return profile, fmt.Sprintf("client %q does not exist", key.Name), nil
+			}
+			return profile, "", fmt.Errorf("failed to get client: %s", err)
+		}
+		profile.secretName = ovpnClient.ObjectRefCertificateSecret().Name
+		profile.key = ovpnClient.ProfileKey()
+	}
+
+	// ... and then make sure that it exists
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: connector.Namespace, Name: profile.secretName}
+	if err := r.Get(ctx, key, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return profile, fmt.Sprintf("secret %q does not exist", key.Name), nil
+		}
+		return profile, "", fmt.Errorf("failed to get profile secret: %s", err)
+	}
+	data, ok := secret.Data[profile.key]
+	if !ok {
+		reason := fmt.Sprintf("secret %q does not contain %q", key.Name, profile.key)
+		return profile, reason, nil
+	}
+	profile.hash = hashData(map[string][]byte{profile.key: data})
+	return profile, "", nil
+}
+
+func (r *OvpnConnectorReconciler) updateConfigMap(
+	ctx context.Context, connector *api.OvpnConnector, logger *zap.Logger,
+) (string, error) {
+	config := supervisor.ConnectorConfig{
+		Profile:        filepath.Join(ovpnconnector.MountPathProfile, ovpnconnector.ProfileFile),
+		ManagementPort: ovpnconnector.ManagementPort,
+		HealthPort:     ovpnconnector.HealthPort,
+	}
+	for i, forward := range connector.Spec.Forwards {
+		port := strconv.Itoa(int(forward.DefaultedTargetPort()))
+		config.Forwards = append(config.Forwards, supervisor.ForwardConfig{
+			Port:   int(ovpnconnector.ForwardPort(i)),
+			Target: net.JoinHostPort(forward.Host, port),
+		})
+	}
+	data, err := config.Encode()
+	if err != nil {
+		return "", fmt.Errorf("failed to get connector config: %s", err)
+	}
+
+	contents := map[string]string{ovpnconnector.ConfigFile: data}
+	cm := &corev1.ConfigMap{ObjectMeta: connector.ObjectRefConfigMap()}
+	op, err := ctrl.CreateOrUpdate(ctx, r, cm, func() error {
+		cm.Data = contents
+		return ctrl.SetControllerReference(connector, cm, r.scheme)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upsert connector config: %s", err)
+	}
+	logger.Debug("updated connector config", zap.String("operation", string(op)))
+	return hashStringData(contents), nil
+}
+
+func (r *OvpnConnectorReconciler) updateDeployment(
+	ctx context.Context, connector *api.OvpnConnector, profile connectorProfile,
+	podAnnotations map[string]string, logger *zap.Logger,
+) error {
+	deployment := &appsv1.Deployment{ObjectMeta: connector.ObjectRefDeployment()}
+	expected := ovpnconnector.GetDeploymentSpec(
+		connector, r.config.Image, profile.secretName, profile.key, podAnnotations,
+	)
+	op, err := controllerutil.CreateOrPatch(ctx, r, deployment, func() error {
+		deployment.Spec = expected
+		return ctrl.SetControllerReference(connector, deployment, r.scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert deployment: %s", err)
+	}
+	logger.Debug("updated deployment", zap.String("operation", string(op)))
+	return nil
+}
+
+func (r *OvpnConnectorReconciler) updateService(
+	ctx context.Context, connector *api.OvpnConnector, logger *zap.Logger,
+) error {
+	service := &corev1.Service{ObjectMeta: connector.ObjectRefService()}
+
+	// If no ports are forwarded, the service must not exist
+	if len(connector.Spec.Forwards) == 0 {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(service), service); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if !metav1.IsControlledBy(service, connector) {
+			return nil
+		}
+		if err := r.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete service: %s", err)
+		}
+		logger.Debug("deleted service")
+		return nil
+	}
+
+	// Otherwise, we update all fields but the ones set by Kubernetes
+	expected := ovpnconnector.GetServiceSpec(connector)
+	op, err := ctrl.CreateOrUpdate(ctx, r, service, func() error {
+		service.Spec.Type = expected.Type
+		service.Spec.Selector = expected.Selector
+		service.Spec.Ports = expected.Ports
+		return ctrl.SetControllerReference(connector, service, r.scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert service: %s", err)
+	}
+	logger.Debug("updated service", zap.String("operation", string(op)))
+	return nil
+}
+
+// getConnectorState derives the state of the tunnel from the readiness of the running pod of
+// the given connector. It returns the state along with the ready pod of a connected connector or
+// a message describing why the connector is not connected.
+func (r *OvpnConnectorReconciler) getConnectorState(
+	ctx context.Context, connector *api.OvpnConnector,
+) (api.OvpnConnectorState, *corev1.Pod, string, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(
+		ctx, pods, client.InNamespace(connector.Namespace),
+		client.MatchingLabels(ovpnconnector.GetSelectorLabels(connector)),
+	); err != nil {
+		return "", nil, "", fmt.Errorf("failed to list pods: %s", err)
+	}
+
+	running := false
+	for i, pod := range pods.Items {
+		if !pod.DeletionTimestamp.IsZero() || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		running = true
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return api.OvpnConnectorStateConnected, &pods.Items[i], "", nil
+			}
+		}
+	}
+	if !running {
+		return api.OvpnConnectorStatePending, nil, "no pod is running", nil
+	}
+	return api.OvpnConnectorStateConnecting, nil, "tunnel is not established", nil
+}
+
+// getTunnelIP queries the address of the given connector pod within the remote VPN from the
+// state endpoint of the pod.
+func (r *OvpnConnectorReconciler) getTunnelIP(
+	ctx context.Context, pod *corev1.Pod,
+) (string, error) {
+	host := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(ovpnconnector.HealthPort))
+	url := fmt.Sprintf("http://%s%s", host, supervisor.ConnectorStatePath)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid request: %s", err)
+	}
+	response, err := r.http.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to get state: %s", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get state: status %d", response.StatusCode)
+	}
+	state := supervisor.ConnectionState{}
+	if err := json.NewDecoder(response.Body).Decode(&state); err != nil {
+		return "", fmt.Errorf("invalid state: %s", err)
+	}
+	return state.TunnelIP, nil
+}
+
+// updateStatus records the given state in the status of the connector if it changed.
+func (r *OvpnConnectorReconciler) updateStatus(
+	ctx context.Context, connector *api.OvpnConnector, state api.OvpnConnectorState,
+	tunnelIP, message string,
+) error {
+	status := api.OvpnConnectorStatus{
+		State:              state,
+		TunnelIP:           tunnelIP,
+		Message:            message,
+		LastTransitionTime: connector.Status.LastTransitionTime,
+	}
+	if state != connector.Status.State {
+		now := metav1.Now()
+		status.LastTransitionTime = &now
+	}
+	if equality.Semantic.DeepEqual(status, connector.Status) {
+		return nil
+	}
+	connector.Status = status
+	if err := r.Status().Update(ctx, connector); err != nil {
+		return fmt.Errorf("failed to update connector status: %s", err)
+	}
+	return nil
+}
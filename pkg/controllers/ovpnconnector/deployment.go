@@ -0,0 +1,207 @@
+package ovpnconnector
+
+import (
+	"path/filepath"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/supervisor"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	volumeNameConfig  = "config"
+	volumeNameProfile = "profile"
+
+	// MountPathConfig is the mount path of the connector's config.
+	MountPathConfig = "/app"
+	// MountPathProfile is the mount path of the connector's profile.
+	MountPathProfile = "/secrets/profile"
+
+	// ConfigFile is the name of the connector config within the configmap.
+	ConfigFile = "connector.json"
+	// ProfileFile is the name of the profile within its mount path.
+	ProfileFile = "profile.ovpn"
+	// HealthPort is the port at which the connector exposes its health and state.
+	HealthPort = 8080
+	// ManagementPort is the local port of the management interface of OpenVPN.
+	ManagementPort = supervisor.DefaultManagementPort
+
+	// The local ports of forwards are allocated consecutively starting at this port.
+	forwardPortBase = 10000
+
+	supervisorBinary = "/usr/local/bin/meerkat-supervisor"
+	selectorKey      = "app.kubernetes.io/name"
+)
+
+// ForwardPort returns the local port of the connector's container at which connections of the
+// forward with the given index are accepted.
+func ForwardPort(index int) int32 {
+	return int32(forwardPortBase + index)
+}
+
+// GetDeploymentSpec returns the expected deployment spec for the given connector and the provided
+// container image. The image is overridden by the connector's image if set. As a profile can only
+// be used by a single client at a time, the deployment runs a single replica which is recreated.
+func GetDeploymentSpec(
+	connector *api.OvpnConnector, image, secretName, secretKey string,
+	additionalPodAnnotations map[string]string,
+) appsv1.DeploymentSpec {
+	var replicas int32 = 1
+	var revisionLimit int32 = 10
+
+	podAnnotations := map[string]string{}
+	for k, v := range additionalPodAnnotations {
+		podAnnotations[k] = v
+	}
+	for k, v := range connector.Spec.Deployment.PodAnnotations {
+		podAnnotations[k] = v
+	}
+
+	if connector.Spec.Deployment.Image != "" {
+		image = connector.Spec.Deployment.Image
+	}
+	return appsv1.DeploymentSpec{
+		Replicas:             &replicas,
+		RevisionHistoryLimit: &revisionLimit,
+		Strategy: appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		},
+		Selector: &metav1.LabelSelector{
+			MatchLabels: GetSelectorLabels(connector),
+		},
+		Template: corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      GetSelectorLabels(connector),
+				Annotations: podAnnotations,
+			},
+			Spec: getPodSpec(connector, image, secretName, secretKey),
+		},
+	}
+}
+
+// GetServiceSpec returns the expected spec of the service exposing the forwarded ports of the
+// given connector.
+func GetServiceSpec(connector *api.OvpnConnector) corev1.ServiceSpec {
+	ports := []corev1.ServicePort{}
+	for i, forward := range connector.Spec.Forwards {
+		ports = append(ports, corev1.ServicePort{
+			Name:       forward.DefaultedName(),
+			Port:       forward.Port,
+			TargetPort: intstr.FromInt(int(ForwardPort(i))),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+	return corev1.ServiceSpec{
+		Type:     corev1.ServiceTypeClusterIP,
+		Selector: GetSelectorLabels(connector),
+		Ports:    ports,
+	}
+}
+
+// GetSelectorLabels returns the labels of the pods running the given connector.
+func GetSelectorLabels(connector *api.OvpnConnector) map[string]string {
+	return map[string]string{
+		selectorKey: connector.ObjectRefDeployment().Name,
+	}
+}
+
+func getPodSpec(
+	connector *api.OvpnConnector, image, secretName, secretKey string,
+) corev1.PodSpec {
+	var gracePeriod int64 = 30
+	var readMode int32 = 0644
+	var secretMode int32 = 0600
+
+	ports := []corev1.ContainerPort{{
+		Name:          "health",
+		ContainerPort: HealthPort,
+		Protocol:      corev1.ProtocolTCP,
+	}}
+	for i := range connector.Spec.Forwards {
+		ports = append(ports, corev1.ContainerPort{
+			ContainerPort: ForwardPort(i),
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
+	return corev1.PodSpec{
+		Containers: []corev1.Container{{
+			Name:            "openvpn",
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command: []string{
+				supervisorBinary,
+				"-mode", "connector",
+				"-config", filepath.Join(MountPathConfig, ConfigFile),
+			},
+			Ports: ports,
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{corev1.Capability("NET_ADMIN")},
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{{
+				Name:      volumeNameConfig,
+				MountPath: MountPathConfig,
+			}, {
+				Name:      volumeNameProfile,
+				MountPath: MountPathProfile,
+			}},
+			ReadinessProbe:           getProbe("/readyz", 5),
+			LivenessProbe:            getProbe("/healthz", 10),
+			Resources:                corev1.ResourceRequirements{},
+			TerminationMessagePath:   "/dev/termination-log",
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		}},
+		Volumes: []corev1.Volume{{
+			Name: volumeNameConfig,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: connector.ObjectRefConfigMap().Name,
+					},
+					DefaultMode: &readMode,
+				},
+			},
+		}, {
+			Name: volumeNameProfile,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+					Items: []corev1.KeyToPath{{
+						Key:  secretKey,
+						Path: ProfileFile,
+					}},
+					DefaultMode: &secretMode,
+				},
+			},
+		}},
+		RestartPolicy:                 corev1.RestartPolicyAlways,
+		DNSPolicy:                     corev1.DNSClusterFirst,
+		SchedulerName:                 corev1.DefaultSchedulerName,
+		TerminationGracePeriodSeconds: &gracePeriod,
+		SecurityContext:               &corev1.PodSecurityContext{},
+	}
+}
+
+// getProbe returns a probe querying the given health endpoint of the connector. The readiness
+// endpoint checks that the tunnel is established, the liveness endpoint that OpenVPN is running.
+func getProbe(path string, initialDelay int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   path,
+				Port:   intstr.FromString("health"),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		InitialDelaySeconds: initialDelay,
+		TimeoutSeconds:      5,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
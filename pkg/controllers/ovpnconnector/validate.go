@@ -0,0 +1,29 @@
+package ovpnconnector
+
+import (
+	"fmt"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+)
+
+// Validate checks the given connector for inconsistencies which cannot be expressed by the schema
+// of the custom resource.
+func Validate(connector *api.OvpnConnector) error {
+	profile := connector.Spec.Profile
+	if (profile.SecretRef == nil) == (profile.ClientName == "") {
+		return fmt.Errorf("exactly one of secretRef and clientName must be set for the profile")
+	}
+	ports := map[int32]bool{}
+	names := map[string]bool{}
+	for _, forward := range connector.Spec.Forwards {
+		if ports[forward.Port] {
+			return fmt.Errorf("port %d is forwarded more than once", forward.Port)
+		}
+		if names[forward.DefaultedName()] {
+			return fmt.Errorf("name %q is used by multiple forwards", forward.DefaultedName())
+		}
+		ports[forward.Port] = true
+		names[forward.DefaultedName()] = true
+	}
+	return nil
+}
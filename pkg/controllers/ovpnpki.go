@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/controllers/ovpnserver"
+	"github.com/borchero/meerkat-operator/pkg/crypto"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnpkis,verbs=get;list;watch;create;update;patch;delete
+
+// OvpnPKIReconciler reconciles OvpnPKI objects.
+type OvpnPKIReconciler struct {
+	client.Client
+	vaults *VaultProvider
+	scheme *runtime.Scheme
+	logger *zap.Logger
+}
+
+// MustSetupOvpnPKIReconciler initializes a new PKI reconciler and attaches it to the given
+// manager. It panics on failure.
+func MustSetupOvpnPKIReconciler(vaults *VaultProvider, mgr ctrl.Manager, logger *zap.Logger) {
+	reconciler := &OvpnPKIReconciler{
+		Client: mgr.GetClient(),
+		vaults: vaults,
+		scheme: mgr.GetScheme(),
+		logger: logger,
+	}
+	if err := reconciler.setupWithManager(mgr); err != nil {
+		panic(err)
+	}
+}
+
+func (r *OvpnPKIReconciler) setupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.OvpnPKI{}).
+		Owns(&corev1.Secret{}).
+		Watches(
+			&source.Kind{Type: &api.VaultIssuer{}},
+			handler.EnqueueRequestsFromMapFunc(r.pkiRequestsForIssuer),
+		).
+		Watches(
+			&source.Kind{Type: &api.ClusterVaultIssuer{}},
+			handler.EnqueueRequestsFromMapFunc(r.pkiRequestsForIssuer),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.pkiRequestsForIssuer),
+		).
+		Complete(r)
+}
+
+func (r *OvpnPKIReconciler) pkiRequestsForIssuer(obj client.Object) []reconcile.Request {
+	// Changes to issuers and their credentials must be picked up by the PKIs using them
+	issuers := r.vaults.affectedIssuers(obj)
+	if len(issuers) == 0 {
+		return nil
+	}
+	list := &api.OvpnPKIList{}
+	if err := r.List(context.Background(), list); err != nil {
+		r.logger.Error("failed to list PKIs for issuer", zap.Error(err))
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, item := range list.Items {
+		if referencesAny(issuers, item.Namespace, item.Spec.IssuerRef) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+			})
+		}
+	}
+	return requests
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Reconcile reconciles the given request.
+func (r *OvpnPKIReconciler) Reconcile(
+	ctx context.Context, req ctrl.Request,
+) (ctrl.Result, error) {
+	logger := r.logger.With(zap.String("name", req.String()))
+	logger.Info("starting reconciliation")
+
+	// First, we get the PKI - if it cannot be found, we return no error
+	shared := &api.OvpnPKI{}
+	if err := r.Get(ctx, req.NamespacedName, shared); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// If the PKI is being deleted, we purge it from Vault. However, this must wait until no server
+	// uses the PKI anymore.
+	if !shared.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(shared, finalizerIdentifier) {
+			servers, err := r.listServers(ctx, shared)
+			if err != nil {
+				logger.Error("failed to list servers", zap.Error(err))
+				return ctrl.Result{}, err
+			}
+			if len(servers) > 0 {
+				logger.Info(
+					"PKI is still in use, postponing deletion", zap.Int("servers", len(servers)),
+				)
+				return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+			}
+			pki, err := r.vaults.SharedPKI(ctx, shared)
+			if err == nil {
+				err = pki.DisableIfEnabled()
+			} else if apierrors.IsNotFound(err) {
+				// Without its issuer, the PKI cannot be reached anymore
+				logger.Warn("vault issuer not found, skipping purge of PKI", zap.Error(err))
+				err = nil
+			}
+			if err != nil {
+				logger.Error("failed purging PKI", zap.Error(err))
+				return ctrl.Result{}, err
+			}
+			controllerutil.RemoveFinalizer(shared, finalizerIdentifier)
+			if err := r.Update(ctx, shared); err != nil {
+				logger.Error("failed removing finalizer", zap.Error(err))
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Prior to anything, we add our finalizer if required
+	if !controllerutil.ContainsFinalizer(shared, finalizerIdentifier) {
+		controllerutil.AddFinalizer(shared, finalizerIdentifier)
+		if err := r.Update(ctx, shared); err != nil {
+			logger.Error("failed adding finalizer", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Then, we make sure that the PKI is established correctly...
+	logger.Debug("reconciling PKI")
+	pki, err := r.vaults.SharedPKI(ctx, shared)
+	if err != nil {
+		logger.Error("failed to get PKI", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	if err := ensurePKI(
+		pki, shared.Spec.OvpnPkiConfig, shared.Spec.Server, shared.Spec.Clients,
+	); err != nil {
+		logger.Error("failed to reconcile PKI", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+
+	// ... and that the TLS key shared by all servers exists
+	logger.Debug("reconciling shared secret")
+	if err := r.updateSharedSecret(ctx, shared, logger); err != nil {
+		logger.Error("failed to reconcile shared secret", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("reconciliation succeeded")
+	return ctrl.Result{}, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (r *OvpnPKIReconciler) updateSharedSecret(
+	ctx context.Context, shared *api.OvpnPKI, logger *zap.Logger,
+) error {
+	secret := &corev1.Secret{ObjectMeta: shared.ObjectRefSharedSecret()}
+	op, err := ctrl.CreateOrUpdate(ctx, r, secret, func() error {
+		if _, ok := secret.Data[secretKeyTa]; !ok {
+			ta, err := crypto.GenerateTLSAuth()
+			if err != nil {
+				return fmt.Errorf("failed to generate TLS auth: %s", err)
+			}
+			secret.Data = map[string][]byte{secretKeyTa: ta}
+		}
+		return ctrl.SetControllerReference(shared, secret, r.scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert shared secret: %s", err)
+	}
+	logger.Debug("reconciled shared secret", zap.String("operation", string(op)))
+	return nil
+}
+
+func (r *OvpnPKIReconciler) listServers(
+	ctx context.Context, shared *api.OvpnPKI,
+) ([]api.OvpnServer, error) {
+	list := &api.OvpnServerList{}
+	if err := r.List(ctx, list, client.InNamespace(shared.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list servers: %s", err)
+	}
+	result := []api.OvpnServer{}
+	for _, item := range list.Items {
+		if item.Spec.Security.PKIName == shared.Name {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// ensurePKI makes sure that the given PKI is enabled and configured with a root certificate as
+// well as roles for servers and clients.
+func ensurePKI(
+	pki *crypto.PKI, config api.OvpnPkiConfig, server api.OvpnServerCertificateConfig,
+	clients api.OvpnClientCertificateConfig,
+) error {
+	if err := pki.EnsureEnabled(); err != nil {
+		return fmt.Errorf("failed to ensure that PKI engine is enabled: %s", err)
+	}
+	if err := pki.GenerateRootIfRequired(ovpnserver.PKIConfig(config)); err != nil {
+		return fmt.Errorf("failed to ensure root certificate: %s", err)
+	}
+	if err := pki.ConfigureRole("server", ovpnserver.PKIServerConfig(server)); err != nil {
+		return fmt.Errorf("failed to ensure server configuration: %s", err)
+	}
+	if err := pki.ConfigureRole("client", ovpnserver.PKIClientConfig(clients)); err != nil {
+		return fmt.Errorf("failed to ensure client configuration: %s", err)
+	}
+	return nil
+}
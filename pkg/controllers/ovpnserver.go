@@ -1,52 +1,76 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 	"github.com/borchero/meerkat-operator/pkg/controllers/ovpnserver"
 	"github.com/borchero/meerkat-operator/pkg/crypto"
 	"github.com/borchero/meerkat-operator/pkg/ovpn"
-	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/borchero/meerkat-operator/pkg/supervisor"
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnservers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=meerkat.borchero.com,resources=ovpnservers/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=secrets;configmaps;services,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
 // OvpnServerReconciler reconciles OvpnServer objects.
 type OvpnServerReconciler struct {
 	client.Client
-	config Config
-	vault  *vaultapi.Client
-	scheme *runtime.Scheme
-	logger *zap.Logger
+	config   Config
+	vaults   *VaultProvider
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	logger   *zap.Logger
+
+	controller        controller.Controller
+	routeWatches      bool
+	routeWatchesMutex sync.Mutex
 }
 
 // MustSetupOvpnServerReconciler initializes a new server reconciler and attaches it to the given
 // manager. It panics on failure.
 func MustSetupOvpnServerReconciler(
-	config Config, vault *vaultapi.Client, mgr ctrl.Manager, logger *zap.Logger,
+	config Config, vaults *VaultProvider, mgr ctrl.Manager, logger *zap.Logger,
 ) {
 	reconciler := &OvpnServerReconciler{
-		Client: mgr.GetClient(),
-		config: config,
-		vault:  vault,
-		scheme: mgr.GetScheme(),
-		logger: logger,
+		Client:   mgr.GetClient(),
+		config:   config,
+		vaults:   vaults,
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("ovpnserver-controller"),
+		logger:   logger,
 	}
 	if err := reconciler.setupWithManager(mgr); err != nil {
 		panic(err)
@@ -54,29 +78,95 @@ func MustSetupOvpnServerReconciler(
 }
 
 func (r *OvpnServerReconciler) setupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// Endpoints, namespaces and nodes are only watched once a server derives routes from them
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&api.OvpnServer{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ConfigMap{}).
-		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
+		Owns(&policyv1beta1.PodDisruptionBudget{}).
 		Owns(&corev1.Service{}).
-		Complete(r)
+		Watches(
+			&source.Kind{Type: &api.OvpnClient{}},
+			handler.EnqueueRequestsFromMapFunc(serverRequestForClient),
+		).
+		Watches(
+			&source.Kind{Type: &api.VaultIssuer{}},
+			handler.EnqueueRequestsFromMapFunc(r.serverRequestsForIssuer),
+		).
+		Watches(
+			&source.Kind{Type: &api.ClusterVaultIssuer{}},
+			handler.EnqueueRequestsFromMapFunc(r.serverRequestsForIssuer),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.serverRequestsForIssuer),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Service{}},
+			handler.EnqueueRequestsFromMapFunc(r.serverRequestsForRoutes),
+		).
+		Build(r)
+	r.controller = c
+	return err
+}
+
+func serverRequestForClient(obj client.Object) []reconcile.Request {
+	ovpnClient, ok := obj.(*api.OvpnClient)
+	if !ok {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, name := range ovpnClient.AllServerNames() {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: ovpnClient.Namespace, Name: name},
+		})
+	}
+	return requests
+}
+
+func (r *OvpnServerReconciler) serverRequestsForIssuer(obj client.Object) []reconcile.Request {
+	// Changes to issuers and their credentials must be picked up by the servers using them
+	issuers := r.vaults.affectedIssuers(obj)
+	if len(issuers) == 0 {
+		return nil
+	}
+	list := &api.OvpnServerList{}
+	if err := r.List(context.Background(), list); err != nil {
+		r.logger.Error("failed to list servers for issuer", zap.Error(err))
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, item := range list.Items {
+		if referencesAny(issuers, item.Namespace, item.Spec.Security.PKI.IssuerRef) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name},
+			})
+		}
+	}
+	return requests
 }
 
 //-------------------------------------------------------------------------------------------------
 
 const (
-	secretKeyDh            = "dh.pem"
-	secretKeyTa            = "ta.key"
-	secretKeyCrl           = "crl.pem"
-	secretKeyServerCrt     = "server.crt"
-	secretKeyServerKey     = "server.key"
-	secretKeyCaCrt         = "ca.crt"
-	secretKeySerial        = "serial"
-	configMapKeyEntrypoint = "entrypoint.sh"
-	configMapKeyOvpnConfig = "openvpn.conf"
+	secretKeyDh           = "dh.pem"
+	secretKeyTa           = "ta.key"
+	secretKeyCrl          = "crl.pem"
+	secretKeyServerCrt    = "server.crt"
+	secretKeyServerKey    = "server.key"
+	secretKeyCaCrt        = "ca.crt"
+	secretKeySerial       = "serial"
+	configMapKeyVerify    = "verify-client.sh"
+	configMapKeySuspended = "suspended-clients"
+	configMapKeyClients   = "client-configs.json"
 
 	annotationKeyExpiresAt = "meerkat.borchero.com/expires-at"
+	annotationKeyHosts     = "meerkat.borchero.com/hosts"
+
+	annotationKeyEntrypointHash   = "meerkat.borchero.com/entrypoint-hash"
+	annotationKeyConfigHash       = "meerkat.borchero.com/config-hash"
+	annotationKeySharedSecretHash = "meerkat.borchero.com/shared-secret-hash"
 
 	finalizerIdentifier = "finalizers.meerkat.borchero.com"
 )
@@ -99,7 +189,7 @@ func (r *OvpnServerReconciler) Reconcile(
 	if !server.DeletionTimestamp.IsZero() {
 		// In that case, we need to make sure that we delete the PKI associated with the server
 		if controllerutil.ContainsFinalizer(server, finalizerIdentifier) {
-			if err := r.deletePKI(ctx, server); err != nil {
+			if err := r.deletePKI(ctx, server, logger); err != nil {
 				logger.Error("failed purging PKI", zap.Error(err))
 				return ctrl.Result{}, err
 			}
@@ -124,10 +214,25 @@ func (r *OvpnServerReconciler) Reconcile(
 		}
 	}
 
+	// Before reconciling, we make sure that the configuration is consistent. Invalid servers are
+	// not requeued as they need to be changed to become valid, the user is notified via an event
+	// and the server's status instead.
+	validationErr := ovpnserver.Validate(server)
+	if err := r.updateValidCondition(ctx, server, validationErr); err != nil {
+		logger.Error("failed to update validity of server", zap.Error(err))
+		return ctrl.Result{}, err
+	}
+	if validationErr != nil {
+		logger.Error("server configuration is invalid", zap.Error(validationErr))
+		r.recorder.Event(server, corev1.EventTypeWarning, "InvalidSpec", validationErr.Error())
+		return ctrl.Result{}, nil
+	}
+
 	// Otherwise, the server is not being deleted, so we can reconcile. First, we want to ensure
 	// that the shared secrets exist.
 	logger.Debug("reconciling shared secrets")
-	if err := r.updateSharedSecret(ctx, server, logger); err != nil {
+	sharedSecretHash, err := r.updateSharedSecret(ctx, server, logger)
+	if err != nil {
 		logger.Error("failed to reconcile shared secrets", zap.Error(err))
 		return ctrl.Result{}, err
 	}
@@ -139,6 +244,24 @@ func (r *OvpnServerReconciler) Reconcile(
 		return ctrl.Result{}, err
 	}
 
+	// If the server's host is derived from its service, we need the service first and have to wait
+	// until its hosts are known
+	if server.IsHostAuto() {
+		logger.Debug("reconciling service to discover hosts")
+		if err := r.updateService(ctx, server, logger); err != nil {
+			logger.Error("failed to reconcile service", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+		if err := r.updateHosts(ctx, server, logger); err != nil {
+			logger.Error("failed to discover hosts", zap.Error(err))
+			return ctrl.Result{}, err
+		}
+		if server.PrimaryHost() == "" {
+			logger.Info("waiting for hosts of service to become known")
+			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
+	}
+
 	// As soon as that succeeded, we can create a certificate for the server to use. We use the
 	// `expiresAt` value to set an annotation on the deployment pods to reload them as soon as
 	// a new certificate has been generated.
@@ -149,13 +272,24 @@ func (r *OvpnServerReconciler) Reconcile(
 		return ctrl.Result{}, err
 	}
 
-	// We can then update the configuration, entrypoint, deployment, and service
+	// We can then update the configuration, entrypoint, stateful set, and service. Pods are
+	// restarted whenever the contents of their configmaps or secrets change, unless the server
+	// reloads its config and shared secrets in place.
 	logger.Debug("reconciling k8s resources")
-	if err := r.updateConfigMaps(ctx, server, logger); err != nil {
+	hashes, err := r.updateConfigMaps(ctx, server, logger)
+	if err != nil {
 		logger.Error("failed to reconcile configmaps", zap.Error(err))
 		return ctrl.Result{}, err
 	}
-	if err := r.updateDeployment(ctx, server, expiresAt, logger); err != nil {
+	podAnnotations := map[string]string{
+		annotationKeyExpiresAt:      expiresAt,
+		annotationKeyEntrypointHash: hashes[annotationKeyEntrypointHash],
+	}
+	if server.Spec.Deployment.DefaultedReloadPolicy() == api.OvpnReloadPolicyRestart {
+		podAnnotations[annotationKeyConfigHash] = hashes[annotationKeyConfigHash]
+		podAnnotations[annotationKeySharedSecretHash] = sharedSecretHash
+	}
+	if err := r.updateDeployment(ctx, server, podAnnotations, logger); err != nil {
 		logger.Error("failed to reconcile deployment", zap.Error(err))
 		return ctrl.Result{}, err
 	}
@@ -165,13 +299,62 @@ func (r *OvpnServerReconciler) Reconcile(
 	}
 
 	logger.Info("reconciliation succeeded")
+	if server.IsHostAuto() &&
+		server.Spec.Service.DefaultedServiceType() == corev1.ServiceTypeNodePort {
+		// Nodes are not watched, so we periodically check whether their IPs changed
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
 //-------------------------------------------------------------------------------------------------
 
-func (r *OvpnServerReconciler) deletePKI(ctx context.Context, server *api.OvpnServer) error {
-	pki := r.getPKI(server)
+// updateValidCondition records in the server's status whether its spec is valid. The status is
+// only updated if the condition changes.
+func (r *OvpnServerReconciler) updateValidCondition(
+	ctx context.Context, server *api.OvpnServer, validationErr error,
+) error {
+	condition := metav1.Condition{
+		Type:               api.OvpnServerConditionValid,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: server.Generation,
+		Reason:             "ValidSpec",
+		Message:            "The server's spec is valid",
+	}
+	if validationErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		condition.Message = validationErr.Error()
+	}
+	current := meta.FindStatusCondition(server.Status.Conditions, condition.Type)
+	if current != nil && current.Status == condition.Status &&
+		current.ObservedGeneration == condition.ObservedGeneration &&
+		current.Message == condition.Message {
+		return nil
+	}
+	meta.SetStatusCondition(&server.Status.Conditions, condition)
+	if err := r.Status().Update(ctx, server); err != nil {
+		return fmt.Errorf("failed to update server status: %s", err)
+	}
+	return nil
+}
+
+func (r *OvpnServerReconciler) deletePKI(
+	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
+) error {
+	// A shared PKI outlives the server and is cleaned up along with the OvpnPKI
+	if server.Spec.Security.PKIName != "" {
+		return nil
+	}
+	pki, err := r.vaults.PKI(ctx, server)
+	if apierrors.IsNotFound(err) {
+		// Without its issuer, the PKI cannot be reached anymore so there is nothing to clean up
+		logger.Warn("vault issuer not found, skipping purge of PKI", zap.Error(err))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 	return pki.DisableIfEnabled()
 }
 
@@ -179,35 +362,63 @@ func (r *OvpnServerReconciler) deletePKI(ctx context.Context, server *api.OvpnSe
 
 func (r *OvpnServerReconciler) updateSharedSecret(
 	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
-) error {
+) (string, error) {
 	secret := &corev1.Secret{ObjectMeta: server.ObjectRefSharedSecrets()}
 
+	// Servers sharing a PKI must also share the TLS key such that clients can use a single
+	// profile for all of them
+	var sharedTa []byte
+	if name := server.Spec.Security.PKIName; name != "" {
+		shared := &api.OvpnPKI{}
+		objectKey := client.ObjectKey{Name: name, Namespace: server.Namespace}
+		if err := r.Get(ctx, objectKey, shared); err != nil {
+			return "", fmt.Errorf("failed to get shared PKI: %s", err)
+		}
+		sharedSecret := &corev1.Secret{ObjectMeta: shared.ObjectRefSharedSecret()}
+		err := r.Get(ctx, client.ObjectKeyFromObject(sharedSecret), sharedSecret)
+		if err != nil {
+			return "", fmt.Errorf("failed to get shared secret of PKI: %s", err)
+		}
+		ta, ok := sharedSecret.Data[secretKeyTa]
+		if !ok {
+			return "", fmt.Errorf("shared secret of PKI does not contain TLS auth")
+		}
+		sharedTa = ta
+	}
+
 	// If the secret already exists and the keys exist, we don't have to do anything
 	err := r.Get(ctx, client.ObjectKeyFromObject(secret), secret)
 	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to check for shared secret: %s", err)
+		return "", fmt.Errorf("failed to check for shared secret: %s", err)
 	}
-	if !apierrors.IsNotFound(err) {
-		_, dhExists := secret.Data[secretKeyDh]
-		_, taExists := secret.Data[secretKeyTa]
-		if dhExists && taExists {
-			return nil
-		}
+	dh, dhExists := secret.Data[secretKeyDh]
+	ta, taExists := secret.Data[secretKeyTa]
+	if sharedTa != nil {
+		taExists = taExists && bytes.Equal(ta, sharedTa)
+	}
+	if dhExists && taExists {
+		return hashData(secret.Data), nil
 	}
 
 	// Otherwise, we need to generate them
-	logger.Info("generating DH parameters, this will take a long time")
-	bits := server.Spec.Security.DiffieHellmanBits
-	if bits == 0 {
-		bits = 2048
-	}
-	dh, err := crypto.GenerateDhParams(bits)
-	if err != nil {
-		return fmt.Errorf("failed to generate DH params: %s", err)
+	if !dhExists {
+		logger.Info("generating DH parameters, this will take a long time")
+		bits := server.Spec.Security.DiffieHellmanBits
+		if bits == 0 {
+			bits = 2048
+		}
+		dh, err = crypto.GenerateDhParams(bits)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate DH params: %s", err)
+		}
 	}
-	ta, err := crypto.GenerateTLSAuth()
-	if err != nil {
-		return fmt.Errorf("failed to generate TLS auth: %s", err)
+	if sharedTa != nil {
+		ta = sharedTa
+	} else if !taExists {
+		ta, err = crypto.GenerateTLSAuth()
+		if err != nil {
+			return "", fmt.Errorf("failed to generate TLS auth: %s", err)
+		}
 	}
 
 	data := map[string][]byte{secretKeyDh: dh, secretKeyTa: ta}
@@ -216,29 +427,29 @@ func (r *OvpnServerReconciler) updateSharedSecret(
 		return ctrl.SetControllerReference(server, secret, r.scheme)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upsert shared secret: %s", err)
+		return "", fmt.Errorf("failed to upsert shared secret: %s", err)
 	}
 	logger.Debug("reconciled shared secret", zap.String("operation", string(op)))
-	return nil
+	return hashData(data), nil
 }
 
 func (r *OvpnServerReconciler) updatePKI(
 	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
 ) error {
-	pki := r.getPKI(server)
-
-	// First, we make sure that everything is configured correctly
-	if err := pki.EnsureEnabled(); err != nil {
-		return fmt.Errorf("failed to ensure that PKI engine is enabled: %s", err)
-	}
-	if err := pki.GenerateRootIfRequired(ovpnserver.PKIConfig(server)); err != nil {
-		return fmt.Errorf("failed to ensure root certificate: %s", err)
-	}
-	if err := pki.ConfigureRole("server", ovpnserver.PKIServerConfig(server)); err != nil {
-		return fmt.Errorf("failed to ensure server configuration: %s", err)
+	pki, err := r.vaults.PKI(ctx, server)
+	if err != nil {
+		return err
 	}
-	if err := pki.ConfigureRole("client", ovpnserver.PKIClientConfig(server)); err != nil {
-		return fmt.Errorf("failed to ensure client configuration: %s", err)
+
+	// First, we make sure that everything is configured correctly. Shared PKIs are configured by
+	// the reconciler of the OvpnPKI.
+	if server.Spec.Security.PKIName == "" {
+		if err := ensurePKI(
+			pki, server.Spec.Security.PKI, server.Spec.Security.Server,
+			server.Spec.Security.Clients,
+		); err != nil {
+			return err
+		}
 	}
 
 	// Then, we pull the CRL into the respective secret
@@ -274,8 +485,13 @@ func (r *OvpnServerReconciler) updateServerCertificate(
 	}
 
 	// If it exists, we parse the expiration date and check if it is far in the future (more than
-	// one sixth of its validity). If so, we return without error
-	if expiresAt, ok := secret.Annotations[annotationKeyExpiresAt]; ok {
+	// one sixth of its validity). If so, we return without error unless the hosts of the server
+	// changed.
+	commonName := server.PrimaryHost()
+	altNames := server.AlternativeNames()
+	hostsValue := strings.Join(append([]string{commonName}, altNames...), ",")
+	if expiresAt, ok := secret.Annotations[annotationKeyExpiresAt]; ok &&
+		secret.Annotations[annotationKeyHosts] == hostsValue {
 		deadline, err := time.Parse(time.RFC3339, expiresAt)
 		if err == nil {
 			remaining := deadline.Sub(time.Now())
@@ -286,19 +502,29 @@ func (r *OvpnServerReconciler) updateServerCertificate(
 	}
 
 	// Otherwise, we issue a new certificate...
-	pki := r.getPKI(server)
+	pki, err := r.vaults.PKI(ctx, server)
+	if err != nil {
+		return "", err
+	}
 	cert, err := pki.Generate(
-		"server", server.Spec.Network.Host, server.Spec.Security.Server.DefaultedValidity(),
+		"server", commonName, server.Spec.Security.Server.DefaultedValidity(),
+		altNames...,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate new certificate: %s", err)
 	}
+	if err := recordIssuedCertificate(
+		ctx, r, server, server, api.OvpnCertificateUsageServer, commonName, cert,
+	); err != nil {
+		return "", err
+	}
 
 	// ... and update the secret accordingly
 	expiresAt := cert.Expiration.Format(time.RFC3339)
 	op, err := ctrl.CreateOrUpdate(ctx, r, secret, func() error {
 		secret.Annotations = map[string]string{
 			annotationKeyExpiresAt: expiresAt,
+			annotationKeyHosts:     hostsValue,
 		}
 		secret.Data = map[string][]byte{
 			secretKeyServerCrt: []byte(cert.Certificate),
@@ -316,95 +542,330 @@ func (r *OvpnServerReconciler) updateServerCertificate(
 
 func (r *OvpnServerReconciler) updateConfigMaps(
 	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
-) error {
-	// First, let's update the entrypoint
+) (map[string]string, error) {
+	// First, let's update the supervisor config along with the script to verify clients
+	gateways, err := r.getGateways(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	suspendedPath := filepath.Join(ovpnserver.MountPathOpenVpnConfig, configMapKeySuspended)
+	listeners := ovpnserver.GetListeners(server)
 	cm := &corev1.ConfigMap{ObjectMeta: server.ObjectRefEntrypointConfigMap()}
-	entrypointValues := ovpn.EntrypointValues{
-		Routes: ovpn.ParseRoutesString(server.Spec.Traffic.Routes),
+	egress := server.Spec.Traffic.Egress
+	supervisorConfig := supervisor.Config{
+		PoolPrefix: ovpnserver.ListenerPoolPrefix,
+		SubnetMask: ovpnserver.ListenerSubnetMask,
+		Egress: supervisor.EgressConfig{
+			Interface:   egress.DefaultedInterface(),
+			Backend:     string(egress.DefaultedBackend()),
+			SNATAddress: string(egress.SNATAddress),
+		},
+		SuspendedClients: suspendedPath,
+		ClientConfigs:    filepath.Join(ovpnserver.MountPathOpenVpnConfig, configMapKeyClients),
+		ClientConfigDir:  ovpnserver.ClientConfigDir,
+		HealthPort:       ovpnserver.HealthPort,
+	}
+	for _, route := range server.Spec.Traffic.Routes {
+		supervisorConfig.Routes = append(supervisorConfig.Routes, string(route.Network))
+	}
+	for _, subnet := range gateways.subnets {
+		supervisorConfig.GatewaySubnets = append(supervisorConfig.GatewaySubnets, string(subnet))
+	}
+	if ipv6 := server.Spec.Network.IPv6; ipv6 != nil {
+		supervisorConfig.IPv6 = &supervisor.IPv6Config{
+			Pool: string(ipv6.DefaultedPool()),
+			Mode: string(ipv6.DefaultedMode()),
+		}
 	}
-	data, err := ovpn.GetEntrypoint(entrypointValues)
+	reload := server.Spec.Deployment.DefaultedReloadPolicy() == api.OvpnReloadPolicyReload
+	for _, listener := range listeners {
+		config := filepath.Join(ovpnserver.MountPathOpenVpnConfig, listener.ConfigFile())
+		supervisorConfig.Servers = append(supervisorConfig.Servers, supervisor.ServerConfig{
+			Name:           listener.Name(),
+			Config:         config,
+			PoolOctet:      listener.PoolOctet(),
+			PoolIndex:      listener.PoolIndex(),
+			ManagementPort: listener.ManagementPort(),
+		})
+		if reload {
+			supervisorConfig.ReloadFiles = append(supervisorConfig.ReloadFiles, config)
+		}
+	}
+	if reload {
+		supervisorConfig.ReloadFiles = append(supervisorConfig.ReloadFiles,
+			filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyDh),
+			filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyTa),
+		)
+	}
+	data, err := supervisorConfig.Encode()
 	if err != nil {
-		return fmt.Errorf("failed to get code for entrypoint: %s", err)
+		return nil, fmt.Errorf("failed to get supervisor config: %s", err)
+	}
+	verify, err := ovpn.GetVerifyScript(ovpn.VerifyValues{SuspendedClients: suspendedPath})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code for client verification: %s", err)
 	}
 
+	// If the transport is wrapped in TLS, the stunnel sidecar is configured alongside
+	var tlsWrap string
+	if server.Spec.Network.Obfuscation.TLSWrap != nil {
+		tlsWrap, err = ovpn.GetStunnelConfig(ovpn.StunnelValues{
+			Services: []ovpn.StunnelService{{
+				Name:    "openvpn",
+				Accept:  fmt.Sprintf("0.0.0.0:%d", ovpnserver.TLSWrapPort),
+				Connect: []string{fmt.Sprintf("127.0.0.1:%d", ovpnserver.ListenerPort)},
+				Cert:    filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerCrt),
+				Key:     filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerKey),
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stunnel config: %s", err)
+		}
+	}
+
+	entrypoint := map[string]string{
+		ovpnserver.SupervisorConfigFile: data,
+		configMapKeyVerify:              verify,
+	}
+	if tlsWrap != "" {
+		entrypoint[ovpnserver.TLSWrapConfigFile] = tlsWrap
+	}
 	op, err := ctrl.CreateOrUpdate(ctx, r, cm, func() error {
-		cm.Data = map[string]string{configMapKeyEntrypoint: data}
+		cm.Data = entrypoint
 		return ctrl.SetControllerReference(server, cm, r.scheme)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upsert server entrypoint: %s", err)
+		return nil, fmt.Errorf("failed to upsert server entrypoint: %s", err)
 	}
 	logger.Debug("updated entrypoint", zap.String("operation", string(op)))
 
-	// Then, update the configuration
+	// Then, update the configuration which consists of one config file per listener. The TCP
+	// listener may share its port with another service.
+	portShare, err := r.getPortShare(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	// Routes derived from the cluster are part of the server's config such that their changes
+	// reach connected clients as the server is reloaded or restarted
+	routes, err := r.getRoutes(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	routes = appendAdvertisedRoutes(routes, gateways.advertised)
+	nameservers, err := r.getNameservers(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	dns := ovpn.ConfigDNS{
+		SearchDomains:   server.Spec.Traffic.DNS.SearchDomains,
+		SplitDomains:    server.Spec.Traffic.DNS.SplitDomains,
+		BlockOutsideDNS: server.Spec.Traffic.DNS.BlockOutsideDNS,
+	}
 	cm = &corev1.ConfigMap{ObjectMeta: server.ObjectRefOvpnConfigMap()}
-	configValues := ovpn.ConfigValues{
-		Nameservers: server.Spec.Traffic.DefaultedNameservers(),
-		RedirectAll: server.Spec.Traffic.RedirectAll,
-		Protocol:    string(server.Spec.Network.Protocol),
-		Routes:      ovpn.ParseRoutes(server.Spec.Traffic.Routes),
-		Security: ovpn.ConfigSecurity{
-			Hmac:   string(server.Spec.Security.DefaultedHmac()),
-			Cipher: string(server.Spec.Security.DefaultedCipher()),
-		},
-		Files: ovpn.ConfigFiles{
-			TLSServerCrt: filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerCrt),
-			TLSServerKey: filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerKey),
-			TLSCaCrt:     filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyCaCrt),
-			DHParams:     filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyDh),
-			TLSAuth:      filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyTa),
-			CRL:          filepath.Join(ovpnserver.MountPathCrl, secretKeyCrl),
-		},
+	configs := map[string]string{}
+	for _, listener := range listeners {
+		configValues := ovpn.ConfigValues{
+			Nameservers:     nameservers,
+			DNS:             dns,
+			RedirectAll:     server.Spec.Traffic.RedirectAll,
+			RedirectFlags:   server.Spec.Traffic.DefaultedRedirectFlags(),
+			IPv6:            server.Spec.Network.IPv6 != nil,
+			ClientToClient:  server.Spec.Traffic.ClientToClient,
+			Protocol:        string(listener.Protocol),
+			Port:            ovpnserver.ListenerPort,
+			Device:          listener.Device(),
+			ManagementPort:  listener.ManagementPort(),
+			StatusFile:      listener.StatusFile(),
+			ClientConfigDir: ovpnserver.ClientConfigDir,
+			PortShare:       portShare,
+			Routes:          ovpn.ParseRoutes(routes),
+			ExcludedRoutes:  ovpn.ParseSubnets(server.Spec.Traffic.ExcludedRoutes),
+			GatewayRoutes:   ovpn.ParseSubnets(gateways.subnets),
+			Security: ovpn.ConfigSecurity{
+				Hmac:   string(server.Spec.Security.DefaultedHmac()),
+				Cipher: string(server.Spec.Security.DefaultedCipher()),
+			},
+			Files: ovpn.ConfigFiles{
+				TLSServerCrt: filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerCrt),
+				TLSServerKey: filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyServerKey),
+				TLSCaCrt:     filepath.Join(ovpnserver.MountPathTLSKeys, secretKeyCaCrt),
+				DHParams:     filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyDh),
+				TLSAuth:      filepath.Join(ovpnserver.MountPathSharedSecrets, secretKeyTa),
+				CRL:          filepath.Join(ovpnserver.MountPathCrl, secretKeyCrl),
+				VerifyClient: filepath.Join(ovpnserver.MountPathEntrypoint, configMapKeyVerify),
+			},
+		}
+		if listener.Protocol != corev1.ProtocolTCP {
+			configValues.PortShare = nil
+		}
+		config, err := ovpn.GetConfig(configValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OVPN config: %s", err)
+		}
+		configs[listener.ConfigFile()] = config
+	}
+
+	// The config also carries the list of suspended clients and the configs of clients which are
+	// both read by the server at runtime
+	suspended, err := r.getSuspendedClients(ctx, server)
+	if err != nil {
+		return nil, err
 	}
-	data, err = ovpn.GetConfig(configValues)
+	clientConfigs, err := json.Marshal(gateways.clientConfigs)
 	if err != nil {
-		return fmt.Errorf("failed to get OVPN config: %s", err)
+		return nil, fmt.Errorf("failed to encode client configs: %s", err)
 	}
 
 	op, err = ctrl.CreateOrUpdate(ctx, r, cm, func() error {
-		cm.Data = map[string]string{configMapKeyOvpnConfig: data}
+		cm.Data = map[string]string{
+			configMapKeySuspended: suspended,
+			configMapKeyClients:   string(clientConfigs),
+		}
+		for key, config := range configs {
+			cm.Data[key] = config
+		}
 		return ctrl.SetControllerReference(server, cm, r.scheme)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upsert server config: %s", err)
+		return nil, fmt.Errorf("failed to upsert server config: %s", err)
 	}
 	logger.Debug("updated server config", zap.String("operation", string(op)))
-	return nil
+
+	// Eventually, we return the hashes of the contents. The list of suspended clients and the
+	// configs of clients are excluded as they are read at runtime.
+	return map[string]string{
+		annotationKeyEntrypointHash: hashStringData(entrypoint),
+		annotationKeyConfigHash:     hashStringData(configs),
+	}, nil
 }
 
 func (r *OvpnServerReconciler) updateDeployment(
-	ctx context.Context, server *api.OvpnServer, certificateExpiration string, logger *zap.Logger,
+	ctx context.Context, server *api.OvpnServer, podAnnotations map[string]string,
+	logger *zap.Logger,
 ) error {
+	// Servers used to be run by deployments, so we first remove a deployment that is still around
 	deployment := &appsv1.Deployment{ObjectMeta: server.ObjectRefDeployment()}
-	expected := ovpnserver.GetDeploymentSpec(server, r.config.Image, map[string]string{
-		annotationKeyExpiresAt: certificateExpiration,
+	err := r.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to check for deployment: %s", err)
+	}
+	if err == nil && metav1.IsControlledBy(deployment, server) {
+		if err := r.Delete(ctx, deployment); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete deployment: %s", err)
+		}
+		logger.Info("deleted deployment in favor of stateful set")
+	}
+
+	// Then, we update the headless service governing the stateful set...
+	headless := &corev1.Service{ObjectMeta: server.ObjectRefHeadlessService()}
+	op, err := ctrl.CreateOrUpdate(ctx, r, headless, func() error {
+		expected := ovpnserver.GetHeadlessServiceSpec(server)
+		headless.Spec.Type = expected.Type
+		headless.Spec.ClusterIP = expected.ClusterIP
+		headless.Spec.Selector = expected.Selector
+		headless.Spec.PublishNotReadyAddresses = expected.PublishNotReadyAddresses
+		return ctrl.SetControllerReference(server, headless, r.scheme)
 	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert headless service: %s", err)
+	}
+	logger.Debug("updated headless service", zap.String("operation", string(op)))
 
-	op, err := controllerutil.CreateOrPatch(ctx, r, deployment, func() error {
+	// ... then the stateful set. Its governing service cannot be changed, so stateful sets which
+	// still reference the service exposing the server are deleted while their pods are kept. The
+	// stateful set is recreated, adopting the pods, once its deletion is observed.
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: server.ObjectRefDeployment()}
+	expected, err := ovpnserver.GetStatefulSetSpec(server, r.config.Image, podAnnotations)
+	if err != nil {
+		return err
+	}
+	err = r.Get(ctx, client.ObjectKeyFromObject(statefulSet), statefulSet)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get stateful set: %s", err)
+	}
+	if err == nil && statefulSet.Spec.ServiceName != expected.ServiceName &&
+		metav1.IsControlledBy(statefulSet, server) {
+		err := r.Delete(ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete stateful set: %s", err)
+		}
+		logger.Info("deleted stateful set to change its governing service")
+		return nil
+	}
+
+	op, err = controllerutil.CreateOrPatch(ctx, r, statefulSet, func() error {
 		if server.Spec.Deployment.Annotations != nil {
-			deployment.Annotations = server.Spec.Deployment.Annotations
+			statefulSet.Annotations = server.Spec.Deployment.Annotations
 		}
-		deployment.Spec = expected
-		return ctrl.SetControllerReference(server, deployment, r.scheme)
+		statefulSet.Spec = expected
+		return ctrl.SetControllerReference(server, statefulSet, r.scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert stateful set: %s", err)
+	}
+	logger.Debug("updated stateful set", zap.String("operation", string(op)))
+
+	// ... and its disruption budget
+	budget := &policyv1beta1.PodDisruptionBudget{ObjectMeta: server.ObjectRefDeployment()}
+	op, err = ctrl.CreateOrUpdate(ctx, r, budget, func() error {
+		budget.Spec = ovpnserver.GetPodDisruptionBudgetSpec(server)
+		return ctrl.SetControllerReference(server, budget, r.scheme)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to upsert deployment: %s", err)
+		return fmt.Errorf("failed to upsert pod disruption budget: %s", err)
 	}
-	logger.Debug("updated deployment", zap.String("operation", string(op)))
+	logger.Debug("updated pod disruption budget", zap.String("operation", string(op)))
 	return nil
 }
 
 func (r *OvpnServerReconciler) updateService(
 	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
 ) error {
-	service := &corev1.Service{ObjectMeta: server.ObjectRefService()}
+	// First, we make sure that all services exposing the server are up to date
+	expected := ovpnserver.GetServices(server)
+	names := map[string]bool{}
+	for _, service := range expected {
+		if err := r.updateServiceObject(ctx, server, service, logger); err != nil {
+			return err
+		}
+		names[service.Meta.Name] = true
+	}
+
+	// Then, we remove additional services for protocols which are no longer exposed separately
+	for _, protocol := range []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP} {
+		service := &corev1.Service{ObjectMeta: server.ObjectRefProtocolService(protocol)}
+		if names[service.Name] {
+			continue
+		}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(service), service); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to fetch additional service: %s", err)
+		}
+		if !metav1.IsControlledBy(service, server) {
+			continue
+		}
+		if err := r.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete out-of-date service: %s", err)
+		}
+		logger.Debug("deleted additional service", zap.String("service", service.Name))
+	}
+	return nil
+}
+
+func (r *OvpnServerReconciler) updateServiceObject(
+	ctx context.Context, server *api.OvpnServer, desired ovpnserver.Service, logger *zap.Logger,
+) error {
+	service := &corev1.Service{ObjectMeta: desired.Meta}
+	logger = logger.With(zap.String("service", service.Name))
 
 	// First, we need to get the service
 	err := r.Get(ctx, client.ObjectKeyFromObject(service), service)
 	if err != nil && !apierrors.IsNotFound(err) {
 		return fmt.Errorf("failed to fetch current service: %s", err)
 	}
-	expected := ovpnserver.GetServiceSpec(server)
+	expected := desired.Spec
 
 	// If it exists, we need to check the type
 	if !apierrors.IsNotFound(err) {
@@ -414,6 +875,7 @@ func (r *OvpnServerReconciler) updateService(
 				return fmt.Errorf("failed to delete out-of-date service: %s", err)
 			}
 			logger.Debug("deleted old service")
+			service = &corev1.Service{ObjectMeta: desired.Meta}
 		} else {
 			// Otherwise, we potentially update if there are changes
 			updated := false
@@ -425,14 +887,28 @@ func (r *OvpnServerReconciler) updateService(
 				service.Spec.Selector = expected.Selector
 				updated = true
 			}
-			if expected.Type == corev1.ServiceTypeLoadBalancer && len(service.Spec.Ports) > 0 {
-				// We need to make sure that the expected node port is not 0
-				expected.Ports[0].NodePort = service.Spec.Ports[0].NodePort
+			if expected.Type == corev1.ServiceTypeLoadBalancer {
+				// We need to make sure that the expected node ports are not 0
+				for i := range expected.Ports {
+					for _, port := range service.Spec.Ports {
+						if port.Name == expected.Ports[i].Name &&
+							port.Protocol == expected.Ports[i].Protocol {
+							expected.Ports[i].NodePort = port.NodePort
+						}
+					}
+				}
 			}
 			if !equality.Semantic.DeepEqual(service.Spec.Ports, expected.Ports) {
 				service.Spec.Ports = expected.Ports
 				updated = true
 			}
+			if expected.IPFamilyPolicy != nil && (service.Spec.IPFamilyPolicy == nil ||
+				*service.Spec.IPFamilyPolicy != *expected.IPFamilyPolicy) {
+				// Families may only be added, so the primary family of the service is retained
+				service.Spec.IPFamilyPolicy = expected.IPFamilyPolicy
+				service.Spec.IPFamilies = getIPFamilies(service.Spec.IPFamilies, expected)
+				updated = true
+			}
 			if updated {
 				if err := r.Update(ctx, service); err != nil {
 					return fmt.Errorf("failed to update out-of-date service: %s", err)
@@ -456,10 +932,161 @@ func (r *OvpnServerReconciler) updateService(
 	return nil
 }
 
+// getIPFamilies returns the IP families of a service given its current families and its expected
+// spec. Single-stack services keep their primary family.
+func getIPFamilies(current []corev1.IPFamily, expected corev1.ServiceSpec) []corev1.IPFamily {
+	if len(current) == 0 {
+		return expected.IPFamilies
+	}
+	if *expected.IPFamilyPolicy == corev1.IPFamilyPolicySingleStack {
+		return current[:1]
+	}
+	for _, family := range expected.IPFamilies {
+		if family != current[0] {
+			return []corev1.IPFamily{current[0], family}
+		}
+	}
+	return current
+}
+
 //-------------------------------------------------------------------------------------------------
 
-func (r *OvpnServerReconciler) getPKI(server *api.OvpnServer) *crypto.PKI {
-	return crypto.NewPKI(
-		r.vault, fmt.Sprintf("%s/%s/%s", r.config.PKIPath, server.Namespace, server.Name),
+func (r *OvpnServerReconciler) updateHosts(
+	ctx context.Context, server *api.OvpnServer, logger *zap.Logger,
+) error {
+	// First, we discover the hosts depending on the type of service
+	hosts := []string{}
+	if server.Spec.Service.DefaultedServiceType() == corev1.ServiceTypeLoadBalancer {
+		service := &corev1.Service{ObjectMeta: server.ObjectRefService()}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(service), service); err != nil {
+			return fmt.Errorf("failed to get service: %s", err)
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				hosts = append(hosts, ingress.Hostname)
+			} else if ingress.IP != "" {
+				hosts = append(hosts, ingress.IP)
+			}
+		}
+	} else {
+		nodes := &corev1.NodeList{}
+		if err := r.List(ctx, nodes); err != nil {
+			return fmt.Errorf("failed to list nodes: %s", err)
+		}
+		for _, node := range nodes.Items {
+			for _, address := range node.Status.Addresses {
+				if address.Type == corev1.NodeExternalIP {
+					hosts = append(hosts, address.Address)
+				}
+			}
+		}
+		sort.Strings(hosts)
+	}
+
+	// Then, we record them in the status if they changed. This triggers the re-rendering of all
+	// client profiles.
+	if equality.Semantic.DeepEqual(hosts, server.Status.Hosts) ||
+		(len(hosts) == 0 && len(server.Status.Hosts) == 0) {
+		return nil
+	}
+	server.Status.Hosts = hosts
+	if err := r.Status().Update(ctx, server); err != nil {
+		return fmt.Errorf("failed to update server status: %s", err)
+	}
+	logger.Info("discovered hosts", zap.Strings("hosts", hosts))
+	return nil
+}
+
+func (r *OvpnServerReconciler) getPortShare(
+	ctx context.Context, server *api.OvpnServer,
+) (*ovpn.ConfigPortShare, error) {
+	portShare := server.Spec.Network.PortShare
+	if portShare == nil {
+		return nil, nil
+	}
+
+	// The service is referenced by its DNS name such that it may be recreated, but we check that
+	// it actually exposes the port
+	namespace := portShare.Namespace
+	if namespace == "" {
+		namespace = server.Namespace
+	}
+	service := &corev1.Service{}
+	objectKey := client.ObjectKey{Name: portShare.ServiceName, Namespace: namespace}
+	if err := r.Get(ctx, objectKey, service); err != nil {
+		return nil, fmt.Errorf("failed to get service to share port with: %s", err)
+	}
+	for _, port := range service.Spec.Ports {
+		if port.Port == int32(portShare.DefaultedPort()) && port.Protocol == corev1.ProtocolTCP {
+			return &ovpn.ConfigPortShare{
+				Host: portShare.Host(server.Namespace),
+				Port: int(portShare.DefaultedPort()),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"service to share port with does not expose TCP port %d", portShare.DefaultedPort(),
 	)
 }
+
+func (r *OvpnServerReconciler) listClients(
+	ctx context.Context, server *api.OvpnServer,
+) ([]api.OvpnClient, error) {
+	list := &api.OvpnClientList{}
+	if err := r.List(ctx, list, client.InNamespace(server.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list clients: %s", err)
+	}
+	result := []api.OvpnClient{}
+	for _, item := range list.Items {
+		if item.IsAssociatedWith(server.Name) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func (r *OvpnServerReconciler) getSuspendedClients(
+	ctx context.Context, server *api.OvpnServer,
+) (string, error) {
+	clients, err := r.listClients(ctx, server)
+	if err != nil {
+		return "", err
+	}
+	names := []string{}
+	for _, c := range clients {
+		if c.Spec.Suspended {
+			names = append(names, c.Spec.CommonName)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return "", nil
+	}
+	return strings.Join(names, "\n") + "\n", nil
+}
+
+// hashData returns a hash of the given contents of a secret, independent of the order of keys.
+func hashData(data map[string][]byte) string {
+	keys := []string{}
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(data[key])
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// hashStringData returns a hash of the given contents of a configmap.
+func hashStringData(data map[string]string) string {
+	converted := map[string][]byte{}
+	for key, value := range data {
+		converted[key] = []byte(value)
+	}
+	return hashData(converted)
+}
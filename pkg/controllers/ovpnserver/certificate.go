@@ -6,32 +6,32 @@ import (
 )
 
 // PKIConfig returns the PKI configuration for the PKI.
-func PKIConfig(server *api.OvpnServer) crypto.PKIConfig {
+func PKIConfig(config api.OvpnPkiConfig) crypto.PKIConfig {
 	return crypto.PKIConfig{
-		CommonName:         server.Spec.Security.PKI.DN.DefaultedCommonName(),
-		Validity:           server.Spec.Security.PKI.DefaultedValidity(),
-		RSABits:            server.Spec.Security.PKI.DefaultedRSABits(),
-		Organization:       server.Spec.Security.PKI.DN.Organization,
-		OrganizationalUnit: server.Spec.Security.PKI.DN.OrganizationalUnit,
-		Country:            server.Spec.Security.PKI.DN.Country,
-		Locality:           server.Spec.Security.PKI.DN.Locality,
+		CommonName:         config.DN.DefaultedCommonName(),
+		Validity:           config.DefaultedValidity(),
+		RSABits:            config.DefaultedRSABits(),
+		Organization:       config.DN.Organization,
+		OrganizationalUnit: config.DN.OrganizationalUnit,
+		Country:            config.DN.Country,
+		Locality:           config.DN.Locality,
 	}
 }
 
 // PKIServerConfig returns the PKI configuration for the server component.
-func PKIServerConfig(server *api.OvpnServer) crypto.PKIRoleConfig {
+func PKIServerConfig(config api.OvpnServerCertificateConfig) crypto.PKIRoleConfig {
 	return crypto.PKIRoleConfig{
-		DefaultValidity: server.Spec.Security.Server.DefaultedValidity(),
-		RSABits:         server.Spec.Security.Server.DefaultedRSABits(),
+		DefaultValidity: config.DefaultedValidity(),
+		RSABits:         config.DefaultedRSABits(),
 		Server:          true,
 	}
 }
 
 // PKIClientConfig returns the PKI configuration for the client component.
-func PKIClientConfig(server *api.OvpnServer) crypto.PKIRoleConfig {
+func PKIClientConfig(config api.OvpnClientCertificateConfig) crypto.PKIRoleConfig {
 	return crypto.PKIRoleConfig{
-		DefaultValidity: server.Spec.Security.Clients.DefaultedValidity(),
-		RSABits:         server.Spec.Security.Clients.DefaultedRSABits(),
+		DefaultValidity: config.DefaultedValidity(),
+		RSABits:         config.DefaultedRSABits(),
 		Server:          false,
 	}
 }
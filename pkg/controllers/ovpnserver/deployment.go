@@ -1,10 +1,17 @@
 package ovpnserver
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
 	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
 const (
@@ -24,18 +31,26 @@ const (
 	MountPathSharedSecrets = "/secrets/shared"
 	// MountPathCrl is the mount for the PKI CRL.
 	MountPathCrl = "/secrets/crl"
+	// ClientConfigDir is the directory that the supervisor writes the configs of clients to.
+	ClientConfigDir = "/run/openvpn/ccd"
+
+	// SupervisorConfigFile is the name of the supervisor config within the entrypoint configmap.
+	SupervisorConfigFile = "supervisor.json"
+	supervisorBinary     = "/usr/local/bin/meerkat-supervisor"
 
 	selectorKey = "app.kubernetes.io/name"
 )
 
-// GetDeploymentSpec returns the expected deployment spec for the given server and the provided
-// container image.
-func GetDeploymentSpec(
+// GetStatefulSetSpec returns the expected stateful set spec for the given server and the provided
+// container image. The image is overridden by the server's image if set and the server's pod
+// template is applied on top of the generated one. A stateful set is used as the ordinals of its
+// pods determine the address pools of the replicas.
+func GetStatefulSetSpec(
 	server *api.OvpnServer, image string, additionalPodAnnotations map[string]string,
-) appsv1.DeploymentSpec {
-	var replicas int32 = 1
-	var progressDeadline int32 = 600
+) (appsv1.StatefulSetSpec, error) {
+	replicas := server.Spec.Deployment.DefaultedReplicas()
 	var revisionLimit int32 = 10
+	var partition int32 = 0
 
 	podAnnotations := map[string]string{}
 	for k, v := range additionalPodAnnotations {
@@ -47,43 +62,112 @@ func GetDeploymentSpec(
 		}
 	}
 
-	return appsv1.DeploymentSpec{
-		Replicas:                &replicas,
-		ProgressDeadlineSeconds: &progressDeadline,
-		RevisionHistoryLimit:    &revisionLimit,
-		Strategy: appsv1.DeploymentStrategy{
-			Type: appsv1.RecreateDeploymentStrategyType,
+	if server.Spec.Deployment.Image != "" {
+		image = server.Spec.Deployment.Image
+	}
+	template, err := applyPodTemplate(server, corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      getSelectorLabels(server),
+			Annotations: podAnnotations,
 		},
-		Selector: &metav1.LabelSelector{
-			MatchLabels: map[string]string{
-				selectorKey: server.ObjectRefDeployment().Name,
+		Spec: getPodSpec(server, image),
+	})
+	if err != nil {
+		return appsv1.StatefulSetSpec{}, err
+	}
+
+	return appsv1.StatefulSetSpec{
+		Replicas:             &replicas,
+		RevisionHistoryLimit: &revisionLimit,
+		ServiceName:          server.ObjectRefHeadlessService().Name,
+		PodManagementPolicy:  appsv1.ParallelPodManagement,
+		UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+				Partition: &partition,
 			},
 		},
-		Template: corev1.PodTemplateSpec{
-			ObjectMeta: metav1.ObjectMeta{
-				Labels: map[string]string{
-					selectorKey: server.ObjectRefDeployment().Name,
-				},
-				Annotations: podAnnotations,
-			},
-			Spec: getPodSpec(server, image),
+		Selector: &metav1.LabelSelector{
+			MatchLabels: getSelectorLabels(server),
+		},
+		Template: template,
+	}, nil
+}
+
+// GetPodDisruptionBudgetSpec returns the expected spec of the disruption budget for the given
+// server. It allows for a single replica to be unavailable at a time.
+func GetPodDisruptionBudgetSpec(server *api.OvpnServer) policyv1beta1.PodDisruptionBudgetSpec {
+	maxUnavailable := intstr.FromInt(1)
+	return policyv1beta1.PodDisruptionBudgetSpec{
+		MaxUnavailable: &maxUnavailable,
+		Selector: &metav1.LabelSelector{
+			MatchLabels: getSelectorLabels(server),
 		},
 	}
 }
 
+func getSelectorLabels(server *api.OvpnServer) map[string]string {
+	return map[string]string{
+		selectorKey: server.ObjectRefDeployment().Name,
+	}
+}
+
+func applyPodTemplate(
+	server *api.OvpnServer, template corev1.PodTemplateSpec,
+) (corev1.PodTemplateSpec, error) {
+	overlay := server.Spec.Deployment.PodTemplate
+	if overlay == nil || len(overlay.Raw) == 0 {
+		return template, nil
+	}
+
+	// First, we apply the server's template as strategic merge patch...
+	original, err := json.Marshal(template)
+	if err != nil {
+		return template, fmt.Errorf("failed to encode pod template: %s", err)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(
+		original, overlay.Raw, corev1.PodTemplateSpec{},
+	)
+	if err != nil {
+		return template, fmt.Errorf("failed to apply pod template of server: %s", err)
+	}
+	result := corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return template, fmt.Errorf("failed to decode patched pod template: %s", err)
+	}
+
+	// ... and make sure that the pod is still matched by the deployment's selector
+	if result.Labels == nil {
+		result.Labels = map[string]string{}
+	}
+	result.Labels[selectorKey] = server.ObjectRefDeployment().Name
+	return result, nil
+}
+
 func getPodSpec(server *api.OvpnServer, image string) corev1.PodSpec {
 	var gracePeriod int64 = 30
-	return corev1.PodSpec{
+	spec := corev1.PodSpec{
 		Containers: []corev1.Container{{
 			Name:            "openvpn",
 			Image:           image,
 			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command: []string{
+				supervisorBinary,
+				"-config", filepath.Join(MountPathEntrypoint, SupervisorConfigFile),
+			},
+			Ports: []corev1.ContainerPort{{
+				Name:          "health",
+				ContainerPort: HealthPort,
+				Protocol:      corev1.ProtocolTCP,
+			}},
 			SecurityContext: &corev1.SecurityContext{
 				Capabilities: &corev1.Capabilities{
 					Add: []corev1.Capability{corev1.Capability("NET_ADMIN")},
 				},
 			},
 			VolumeMounts:             getVolumeMounts(server),
+			ReadinessProbe:           getProbe("/readyz", 5),
+			LivenessProbe:            getProbe("/healthz", 10),
 			Resources:                corev1.ResourceRequirements{},
 			TerminationMessagePath:   "/dev/termination-log",
 			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
@@ -94,6 +178,61 @@ func getPodSpec(server *api.OvpnServer, image string) corev1.PodSpec {
 		SchedulerName:                 corev1.DefaultSchedulerName,
 		TerminationGracePeriodSeconds: &gracePeriod,
 		SecurityContext:               &corev1.PodSecurityContext{},
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelHostname,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: getSelectorLabels(server)},
+		}, {
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelTopologyZone,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: getSelectorLabels(server)},
+		}},
+	}
+
+	// If the transport is wrapped in TLS, stunnel terminates TLS using the server certificate and
+	// forwards the traffic to OpenVPN
+	if server.Spec.Network.Obfuscation.TLSWrap != nil {
+		spec.Containers = append(spec.Containers, corev1.Container{
+			Name:            "stunnel",
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command: []string{
+				"stunnel", filepath.Join(MountPathEntrypoint, TLSWrapConfigFile),
+			},
+			VolumeMounts: []corev1.VolumeMount{{
+				Name:      volumeNameEntrypoint,
+				MountPath: MountPathEntrypoint,
+			}, {
+				Name:      volumeNameTLSKeys,
+				MountPath: MountPathTLSKeys,
+			}},
+			Resources:                corev1.ResourceRequirements{},
+			TerminationMessagePath:   "/dev/termination-log",
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		})
+	}
+	return spec
+}
+
+// getProbe returns a probe querying the given health endpoint of the supervisor. The readiness
+// endpoint checks that the management interfaces of all OpenVPN processes are available, i.e.
+// that all of them have been initialized, the liveness endpoint that all of them are running.
+func getProbe(path string, initialDelay int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   path,
+				Port:   intstr.FromString("health"),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		InitialDelaySeconds: initialDelay,
+		TimeoutSeconds:      5,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
 	}
 }
 
@@ -0,0 +1,91 @@
+package ovpnserver
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ListenerPort is the port that all OpenVPN processes listen on within the pod.
+	ListenerPort = 1194
+	// ListenerSubnetMask is the subnet mask of the address pool of each OpenVPN process.
+	ListenerSubnetMask = "255.255.255.0"
+	// ListenerPoolPrefix is the prefix of the addresses of all pools.
+	ListenerPoolPrefix = "192.168"
+	// TLSWrapPort is the port that the stunnel sidecar listens on within the pod.
+	TLSWrapPort = 8443
+	// TLSWrapConfigFile is the name of the stunnel config file within the entrypoint configmap.
+	TLSWrapConfigFile = "stunnel.conf"
+	// HealthPort is the port at which the supervisor exposes its health endpoints.
+	HealthPort = 8080
+)
+
+// Listener describes an OpenVPN process serving a single protocol. Each listener uses its own
+// tunnel device, management port and address pools, one for each replica.
+type Listener struct {
+	Index    int
+	Protocol corev1.Protocol
+	Port     uint16
+	Replicas int32
+}
+
+// GetListeners returns the listeners required for the given server, one for each protocol.
+func GetListeners(server *api.OvpnServer) []Listener {
+	result := []Listener{}
+	for i, protocol := range server.DefaultedProtocols() {
+		result = append(result, Listener{
+			Index:    i,
+			Protocol: protocol.Protocol,
+			Port:     protocol.Port,
+			Replicas: server.Spec.Deployment.DefaultedReplicas(),
+		})
+	}
+	return result
+}
+
+// Name returns the lowercase name of the listener's protocol.
+func (l Listener) Name() string {
+	return strings.ToLower(string(l.Protocol))
+}
+
+// ConfigFile returns the name of the OpenVPN config file of the listener. The first listener uses
+// the same file name as servers serving a single protocol always did.
+func (l Listener) ConfigFile() string {
+	if l.Index == 0 {
+		return "openvpn.conf"
+	}
+	return fmt.Sprintf("openvpn-%s.conf", l.Name())
+}
+
+// StatusFile returns the path of the file that the listener writes its status to.
+func (l Listener) StatusFile() string {
+	if l.Index == 0 {
+		return "/tmp/openvpn.log"
+	}
+	return fmt.Sprintf("/tmp/openvpn-%s.log", l.Name())
+}
+
+// Device returns the name of the tunnel device of the listener.
+func (l Listener) Device() string {
+	return fmt.Sprintf("tun%d", l.Index)
+}
+
+// ManagementPort returns the local port of the listener's management interface.
+func (l Listener) ManagementPort() int {
+	return 7505 + l.Index
+}
+
+// PoolIndex returns the index of the address pool of the listener's first replica among all
+// pools of the server. The replica with ordinal i uses the pool whose index is larger by i.
+func (l Listener) PoolIndex() int {
+	return l.Index * int(l.Replicas)
+}
+
+// PoolOctet returns the third octet of the IPv4 address pool of the listener's first replica.
+// Pools are /24 networks allocated downwards from 192.168.255.0 in the order of their indices.
+func (l Listener) PoolOctet() int {
+	return 255 - l.PoolIndex()
+}
@@ -3,26 +3,119 @@ package ovpnserver
 import (
 	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
-// GetServiceSpec returns the expected service spec for the given server.
-func GetServiceSpec(server *api.OvpnServer) corev1.ServiceSpec {
-	var nodePort int32
-	if server.Spec.Service.DefaultedServiceType() == corev1.ServiceTypeNodePort {
-		nodePort = int32(server.Spec.Service.DefaultedPort())
+// Service describes a service exposing (some of) the ports of a server.
+type Service struct {
+	Meta metav1.ObjectMeta
+	Spec corev1.ServiceSpec
+}
+
+type servicePort struct {
+	name       string
+	protocol   corev1.Protocol
+	port       uint16
+	targetPort int
+}
+
+// GetServices returns the expected services for the given server. A single service exposes all
+// ports unless it is a `LoadBalancer` which may not mix protocols. In this case, the ports of
+// every protocol but the first are exposed by an additional service.
+func GetServices(server *api.OvpnServer) []Service {
+	ports := getServicePorts(server)
+	if len(ports) == 1 || server.Spec.Service.MixedProtocol ||
+		server.Spec.Service.DefaultedServiceType() != corev1.ServiceTypeLoadBalancer {
+		return []Service{{
+			Meta: server.ObjectRefService(),
+			Spec: getServiceSpec(server, ports),
+		}}
 	}
-	return corev1.ServiceSpec{
-		Type: server.Spec.Service.DefaultedServiceType(),
-		Selector: map[string]string{
-			selectorKey: server.ObjectRefDeployment().Name,
-		},
-		Ports: []corev1.ServicePort{{
-			Name:       "ovpn",
-			Protocol:   server.Spec.Network.DefaultedProtocol(),
-			Port:       int32(server.Spec.Service.DefaultedPort()),
-			TargetPort: intstr.FromInt(1194),
+
+	protocols := []corev1.Protocol{}
+	groups := map[corev1.Protocol][]servicePort{}
+	for _, port := range ports {
+		if _, ok := groups[port.protocol]; !ok {
+			protocols = append(protocols, port.protocol)
+		}
+		groups[port.protocol] = append(groups[port.protocol], port)
+	}
+	result := []Service{}
+	for i, protocol := range protocols {
+		meta := server.ObjectRefService()
+		if i > 0 {
+			meta = server.ObjectRefProtocolService(protocol)
+		}
+		result = append(result, Service{Meta: meta, Spec: getServiceSpec(server, groups[protocol])})
+	}
+	return result
+}
+
+func getServicePorts(server *api.OvpnServer) []servicePort {
+	result := []servicePort{}
+	for _, listener := range GetListeners(server) {
+		result = append(result, servicePort{
+			name:       listener.Name(),
+			protocol:   listener.Protocol,
+			port:       listener.Port,
+			targetPort: ListenerPort,
+		})
+	}
+	if wrap := server.Spec.Network.Obfuscation.TLSWrap; wrap != nil {
+		result = append(result, servicePort{
+			name:       "tls",
+			protocol:   corev1.ProtocolTCP,
+			port:       wrap.DefaultedPort(),
+			targetPort: TLSWrapPort,
+		})
+	}
+	return result
+}
+
+func getServiceSpec(server *api.OvpnServer, ports []servicePort) corev1.ServiceSpec {
+	servicePorts := []corev1.ServicePort{}
+	for _, port := range ports {
+		var nodePort int32
+		if server.Spec.Service.DefaultedServiceType() == corev1.ServiceTypeNodePort {
+			nodePort = int32(port.port)
+		}
+		name := "ovpn"
+		if len(ports) > 1 {
+			name = "ovpn-" + port.name
+		}
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       name,
+			Protocol:   port.protocol,
+			Port:       int32(port.port),
+			TargetPort: intstr.FromInt(port.targetPort),
 			NodePort:   nodePort,
-		}},
+		})
+	}
+	spec := corev1.ServiceSpec{
+		Type:     server.Spec.Service.DefaultedServiceType(),
+		Selector: getSelectorLabels(server),
+		Ports:    servicePorts,
+	}
+
+	// Dual-stack services list both families, starting with IPv4
+	if policy := server.IPFamilyPolicy(); policy != nil {
+		spec.IPFamilyPolicy = policy
+		if *policy != corev1.IPFamilyPolicySingleStack {
+			spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+		}
+	}
+	return spec
+}
+
+// GetHeadlessServiceSpec returns the expected spec of the headless service governing the stateful
+// set of the given server. Pods are published before they are ready such that each replica can be
+// addressed while it is starting.
+func GetHeadlessServiceSpec(server *api.OvpnServer) corev1.ServiceSpec {
+	return corev1.ServiceSpec{
+		Type:                     corev1.ServiceTypeClusterIP,
+		ClusterIP:                corev1.ClusterIPNone,
+		Selector:                 getSelectorLabels(server),
+		PublishNotReadyAddresses: true,
 	}
 }
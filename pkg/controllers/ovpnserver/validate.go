@@ -0,0 +1,104 @@
+package ovpnserver
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Validate checks the given server for inconsistencies which cannot be expressed by the schema of
+// the custom resource.
+func Validate(server *api.OvpnServer) error {
+	if server.Spec.Network.PortShare != nil && getTCPListener(server) == nil {
+		return fmt.Errorf("sharing the port requires the server to serve TCP")
+	}
+	if wrap := server.Spec.Network.Obfuscation.TLSWrap; wrap != nil {
+		listener := getTCPListener(server)
+		if listener == nil {
+			return fmt.Errorf("wrapping the transport in TLS requires the server to serve TCP")
+		}
+		if listener.Port == wrap.DefaultedPort() {
+			return fmt.Errorf("port of TLS endpoint collides with port of TCP")
+		}
+	}
+	if err := validateAddresses(server); err != nil {
+		return err
+	}
+	return validateDNS(server)
+}
+
+// domainPattern matches DNS names, optionally fully qualified.
+var domainPattern = regexp.MustCompile(
+	`^[a-zA-Z0-9_]([a-zA-Z0-9_-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9_]([a-zA-Z0-9_-]*[a-zA-Z0-9])?)*\.?$`,
+)
+
+func validateDNS(server *api.OvpnServer) error {
+	dns := server.Spec.Traffic.DNS
+	if dns.BlockOutsideDNS && len(dns.SplitDomains) > 0 {
+		return fmt.Errorf("blocking DNS outside the VPN cannot be combined with split domains")
+	}
+	for _, domain := range append(append([]string{}, dns.SearchDomains...), dns.SplitDomains...) {
+		if !domainPattern.MatchString(domain) {
+			return fmt.Errorf("invalid domain %q", domain)
+		}
+	}
+	return nil
+}
+
+func validateAddresses(server *api.OvpnServer) error {
+	// First, we check that the IPv6 pool is large enough to hold a /64 for every listener and
+	// replica...
+	ipv6 := server.Spec.Network.IPv6
+	if ipv6 != nil {
+		_, pool, err := net.ParseCIDR(string(ipv6.DefaultedPool()))
+		if err != nil || pool.IP.To4() != nil {
+			return fmt.Errorf("IPv6 pool %q is not an IPv6 range", ipv6.DefaultedPool())
+		}
+		bits, _ := pool.Mask.Size()
+		if bits > 56 {
+			return fmt.Errorf("prefix of IPv6 pool must be at most 56 bits long")
+		}
+	}
+
+	// ... and that IPv6 routes and nameservers are only used if the VPN supports IPv6
+	for _, route := range server.Spec.Traffic.Routes {
+		ip, _, err := net.ParseCIDR(string(route.Network))
+		if err != nil {
+			return fmt.Errorf("invalid route %q: %s", route.Network, err)
+		}
+		if ip.To4() == nil && ipv6 == nil {
+			return fmt.Errorf("IPv6 route %q requires IPv6 to be configured", route.Network)
+		}
+	}
+	for _, route := range server.Spec.Traffic.ExcludedRoutes {
+		ip, _, err := net.ParseCIDR(string(route))
+		if err != nil {
+			return fmt.Errorf("invalid excluded route %q: %s", route, err)
+		}
+		if ip.To4() == nil {
+			return fmt.Errorf("excluded route %q is not an IPv4 range", route)
+		}
+	}
+	for _, nameserver := range server.Spec.Traffic.Nameservers {
+		ip := net.ParseIP(string(nameserver))
+		if ip == nil {
+			return fmt.Errorf("invalid nameserver %q", nameserver)
+		}
+		if ip.To4() == nil && ipv6 == nil {
+			return fmt.Errorf("IPv6 nameserver %q requires IPv6 to be configured", nameserver)
+		}
+	}
+	return nil
+}
+
+func getTCPListener(server *api.OvpnServer) *Listener {
+	for _, listener := range GetListeners(server) {
+		if listener.Protocol == corev1.ProtocolTCP {
+			return &listener
+		}
+	}
+	return nil
+}
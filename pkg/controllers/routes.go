@@ -0,0 +1,379 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/ovpn"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// +kubebuilder:rbac:groups=core,resources=endpoints;namespaces,verbs=get;list;watch
+
+// clusterDNSService is the service of the cluster's DNS, named `kube-dns` for compatibility by
+// CoreDNS as well.
+var clusterDNSService = client.ObjectKey{Namespace: "kube-system", Name: "kube-dns"}
+
+// getRoutes returns the routes pushed to the clients of the given server. Besides the routes set
+// explicitly, these are the addresses of the selected services and the cluster CIDRs. IPv6 routes
+// are omitted if the server does not support IPv6.
+func (r *OvpnServerReconciler) getRoutes(
+	ctx context.Context, server *api.OvpnServer,
+) ([]api.OvpnRoute, error) {
+	result := append([]api.OvpnRoute{}, server.Spec.Traffic.Routes...)
+	if !server.Spec.Traffic.HasDynamicRoutes() {
+		return result, nil
+	}
+	if err := r.watchRouteSources(); err != nil {
+		return nil, err
+	}
+
+	// First, we collect the addresses of all selected services...
+	addresses := map[string]bool{}
+	for _, selector := range server.Spec.Traffic.RouteSelectors {
+		selected, err := r.getSelectedAddresses(ctx, server, selector)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range selected {
+			addresses[address] = true
+		}
+	}
+
+	// ... as well as the address of the cluster DNS and the CIDRs of the cluster
+	if server.Spec.Traffic.DNS.UseClusterDNS {
+		clusterDNS, err := r.getClusterDNS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range clusterDNS {
+			addresses[address] = true
+		}
+	}
+	if server.Spec.Traffic.RouteClusterCIDRs {
+		nodes := &corev1.NodeList{}
+		if err := r.List(ctx, nodes); err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %s", err)
+		}
+		for _, node := range nodes.Items {
+			cidrs := node.Spec.PodCIDRs
+			if len(cidrs) == 0 && node.Spec.PodCIDR != "" {
+				cidrs = []string{node.Spec.PodCIDR}
+			}
+			for _, cidr := range cidrs {
+				addresses[cidr] = true
+			}
+		}
+		for _, cidr := range r.config.ServiceCIDRs {
+			addresses[cidr] = true
+		}
+	}
+
+	// Eventually, we normalize all of them to networks. The order is fixed such that the config
+	// only changes if the routes change.
+	seen := map[string]bool{}
+	for _, route := range result {
+		seen[string(route.Network)] = true
+	}
+	dynamic := []string{}
+	for address := range addresses {
+		route, ok := toRoute(address, server.Spec.Network.IPv6 != nil)
+		if ok && !seen[route] {
+			dynamic = append(dynamic, route)
+			seen[route] = true
+		}
+	}
+	sort.Strings(dynamic)
+	for _, route := range dynamic {
+		result = append(result, api.OvpnRoute{Network: api.SubnetMask(route)})
+	}
+	return result, nil
+}
+
+func (r *OvpnServerReconciler) getSelectedAddresses(
+	ctx context.Context, server *api.OvpnServer, selector api.OvpnRouteSelector,
+) ([]string, error) {
+	// First, we find the namespaces to select services from...
+	namespaces := []string{server.Namespace}
+	if selector.NamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(selector.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %s", err)
+		}
+		list := &corev1.NamespaceList{}
+		if err := r.List(
+			ctx, list, client.MatchingLabelsSelector{Selector: namespaceSelector},
+		); err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %s", err)
+		}
+		namespaces = []string{}
+		for _, namespace := range list.Items {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+	serviceSelector := labels.Everything()
+	if selector.ServiceSelector != nil {
+		var err error
+		serviceSelector, err = metav1.LabelSelectorAsSelector(selector.ServiceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service selector: %s", err)
+		}
+	}
+
+	// ... and then collect the cluster IPs and endpoints of the services within them
+	result := []string{}
+	for _, namespace := range namespaces {
+		services := &corev1.ServiceList{}
+		if err := r.List(
+			ctx, services, client.InNamespace(namespace),
+			client.MatchingLabelsSelector{Selector: serviceSelector},
+		); err != nil {
+			return nil, fmt.Errorf("failed to list services: %s", err)
+		}
+		for _, service := range services.Items {
+			result = append(result, service.Spec.ClusterIPs...)
+			if len(service.Spec.ClusterIPs) == 0 {
+				result = append(result, service.Spec.ClusterIP)
+			}
+
+			endpoints := &corev1.Endpoints{}
+			key := client.ObjectKeyFromObject(&service)
+			if err := r.Get(ctx, key, endpoints); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					return nil, fmt.Errorf("failed to get endpoints of service: %s", err)
+				}
+				continue
+			}
+			for _, subset := range endpoints.Subsets {
+				for _, address := range subset.Addresses {
+					result = append(result, address.IP)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// toRoute converts the given address or network into a network in CIDR notation. Single addresses
+// are routed as /32 (or /128). It returns false for invalid addresses such as the cluster IP of
+// headless services and for IPv6 addresses if IPv6 is not supported.
+func toRoute(address string, ipv6 bool) (string, bool) {
+	var network *net.IPNet
+	if _, parsed, err := net.ParseCIDR(address); err == nil {
+		network = parsed
+	} else if ip := net.ParseIP(address); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	} else {
+		return "", false
+	}
+	if network.IP.To4() == nil && !ipv6 {
+		return "", false
+	}
+	return network.String(), true
+}
+
+// getNameservers returns the nameservers pushed to the clients of the given server. The cluster
+// DNS precedes all other nameservers if it is used.
+func (r *OvpnServerReconciler) getNameservers(
+	ctx context.Context, server *api.OvpnServer,
+) ([]string, error) {
+	result := []string{}
+	if server.Spec.Traffic.DNS.UseClusterDNS {
+		clusterDNS, err := r.getClusterDNS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range clusterDNS {
+			if _, ok := toRoute(address, server.Spec.Network.IPv6 != nil); ok {
+				result = append(result, address)
+			}
+		}
+	}
+	return append(result, server.Spec.Traffic.DefaultedNameservers()...), nil
+}
+
+// getClusterDNS returns the cluster IPs of the cluster's DNS service.
+func (r *OvpnServerReconciler) getClusterDNS(ctx context.Context) ([]string, error) {
+	service := &corev1.Service{}
+	if err := r.Get(ctx, clusterDNSService, service); err != nil {
+		return nil, fmt.Errorf("failed to get cluster DNS service: %s", err)
+	}
+	if len(service.Spec.ClusterIPs) > 0 {
+		return service.Spec.ClusterIPs, nil
+	}
+	return []string{service.Spec.ClusterIP}, nil
+}
+
+// gateways describes the clients of a server which act as gateways to networks behind them.
+type gateways struct {
+	// The server-side configs of the clients, keyed by their common names.
+	clientConfigs map[string]string
+	// The networks behind all clients...
+	subnets []api.SubnetMask
+	// ... and the ones among them that are pushed to all clients
+	advertised []api.SubnetMask
+}
+
+// getGateways returns the networks behind the clients of the given server which act as gateways
+// along with the configs routing the networks to the clients. Networks which are claimed by
+// another client already are ignored, as are IPv6 networks if the server does not support IPv6.
+// Servers running multiple replicas ignore all gateways as the replicas cannot route between
+// each other.
+func (r *OvpnServerReconciler) getGateways(
+	ctx context.Context, server *api.OvpnServer,
+) (gateways, error) {
+	clients, err := r.listClients(ctx, server)
+	if err != nil {
+		return gateways{}, err
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Spec.CommonName < clients[j].Spec.CommonName
+	})
+
+	result := gateways{clientConfigs: map[string]string{}}
+
+	seen := map[string]bool{}
+	for i, c := range clients {
+		// Common names are used as file names by the server and must not contain slashes
+		gateway := c.Spec.Gateway
+		if gateway == nil || strings.Contains(c.Spec.CommonName, "/") {
+			continue
+		}
+		if server.Spec.Deployment.DefaultedReplicas() > 1 {
+			r.recorder.Event(&clients[i], corev1.EventTypeWarning, "GatewayIgnored",
+				"Gateways are not supported by servers running multiple replicas")
+			continue
+		}
+		subnets := []api.SubnetMask{}
+		for _, subnet := range gateway.Subnets {
+			route, ok := toRoute(string(subnet), server.Spec.Network.IPv6 != nil)
+			if ok && !seen[route] {
+				subnets = append(subnets, api.SubnetMask(route))
+				seen[route] = true
+			}
+		}
+		if len(subnets) == 0 {
+			continue
+		}
+
+		config, err := ovpn.GetClientConfig(ovpn.ClientConfigValues{
+			Subnets:    ovpn.ParseSubnets(subnets),
+			Advertised: gateway.Advertise,
+		})
+		if err != nil {
+			return gateways{}, fmt.Errorf("failed to get config of client %q: %s", c.Name, err)
+		}
+		result.clientConfigs[c.Spec.CommonName] = config
+		result.subnets = append(result.subnets, subnets...)
+		if gateway.Advertise {
+			result.advertised = append(result.advertised, subnets...)
+		}
+	}
+	return result, nil
+}
+
+// appendAdvertisedRoutes adds the given networks to the routes unless they are routed already.
+func appendAdvertisedRoutes(routes []api.OvpnRoute, advertised []api.SubnetMask) []api.OvpnRoute {
+	seen := map[string]bool{}
+	for _, route := range routes {
+		seen[string(route.Network)] = true
+	}
+	for _, subnet := range advertised {
+		if !seen[string(subnet)] {
+			routes = append(routes, api.OvpnRoute{Network: subnet})
+			seen[string(subnet)] = true
+		}
+	}
+	return routes
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// watchRouteSources watches the endpoints, namespaces and nodes which dynamic routes are derived
+// from. The watches are only registered once the first server requires them such that these
+// objects are not cached by the operator otherwise.
+func (r *OvpnServerReconciler) watchRouteSources() error {
+	r.routeWatchesMutex.Lock()
+	defer r.routeWatchesMutex.Unlock()
+	if r.routeWatches {
+		return nil
+	}
+	sources := []client.Object{&corev1.Endpoints{}, &corev1.Namespace{}, &corev1.Node{}}
+	for _, obj := range sources {
+		if err := r.controller.Watch(
+			&source.Kind{Type: obj},
+			handler.EnqueueRequestsFromMapFunc(r.serverRequestsForRoutes),
+		); err != nil {
+			return fmt.Errorf("failed to watch sources of routes: %s", err)
+		}
+	}
+	r.routeWatches = true
+	return nil
+}
+
+// serverRequestsForRoutes returns requests for all servers whose routes or nameservers may depend
+// on the given object, i.e. a service, its endpoints, a namespace or a node.
+func (r *OvpnServerReconciler) serverRequestsForRoutes(obj client.Object) []reconcile.Request {
+	servers := &api.OvpnServerList{}
+	if err := r.List(context.Background(), servers); err != nil {
+		r.logger.Error("failed to list servers to update routes", zap.Error(err))
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, server := range servers.Items {
+		if !server.Spec.Traffic.HasDynamicRoutes() && !server.Spec.Traffic.DNS.UseClusterDNS {
+			continue
+		}
+		if isRouteSource(&server, obj) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: server.Namespace, Name: server.Name,
+				},
+			})
+		}
+	}
+	return requests
+}
+
+func isRouteSource(server *api.OvpnServer, obj client.Object) bool {
+	switch obj.(type) {
+	case *corev1.Node:
+		return server.Spec.Traffic.RouteClusterCIDRs
+	case *corev1.Namespace:
+		for _, selector := range server.Spec.Traffic.RouteSelectors {
+			if selector.NamespaceSelector != nil {
+				return true
+			}
+		}
+		return false
+	default:
+		// Services and endpoints are only relevant if their namespace may be selected
+		if server.Spec.Traffic.DNS.UseClusterDNS &&
+			obj.GetNamespace() == clusterDNSService.Namespace {
+			return true
+		}
+		for _, selector := range server.Spec.Traffic.RouteSelectors {
+			if selector.NamespaceSelector != nil || obj.GetNamespace() == server.Namespace {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -6,4 +6,10 @@ type Config struct {
 	Image string
 	// The base path to use within Vault for mounting PKIs for the OVPN servers.
 	PKIPath string `split_words:"true"`
+	// The service CIDRs of the cluster which are routed by servers routing cluster CIDRs. Unlike
+	// pod CIDRs, they cannot be discovered from the nodes.
+	ServiceCIDRs []string `split_words:"true"`
+	// The namespace in which the operator runs. Secrets and service accounts referenced by cluster
+	// issuers without a namespace are looked up in it.
+	Namespace string
 }
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"github.com/borchero/meerkat-operator/pkg/crypto"
+	vaultapi "github.com/hashicorp/vault/api"
+	"go.uber.org/zap"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=meerkat.borchero.com,resources=vaultissuers;clustervaultissuers,verbs=get;list;watch
+
+const (
+	// Tokens requested for service accounts are only used to log in, the minimum expiration
+	// therefore suffices.
+	serviceAccountTokenExpiration = 10 * time.Minute
+	// The prefix of all audiences of tokens requested for service accounts. It ensures that the
+	// tokens are never valid for the Kubernetes API itself.
+	serviceAccountAudiencePrefix = "vault://"
+)
+
+// VaultProvider provides the Vault clients for the PKIs of OVPN servers. Servers without an issuer
+// use the operator's global client, all others get a client for the referenced issuer. These
+// clients are cached and rebuilt whenever the issuer changes.
+type VaultProvider struct {
+	client   client.Client
+	accounts corev1client.ServiceAccountsGetter
+	config   Config
+	fallback *vaultapi.Client
+	logger   *zap.Logger
+
+	mutex   sync.Mutex
+	clients map[string]*issuerClient
+}
+
+type issuerClient struct {
+	vault   *vaultapi.Client
+	pkiPath string
+	version string
+	cancel  context.CancelFunc
+}
+
+// NewVaultProvider initializes a new provider which falls back to the given client for servers
+// that do not reference an issuer.
+func NewVaultProvider(
+	config Config, fallback *vaultapi.Client, mgr ctrl.Manager, logger *zap.Logger,
+) *VaultProvider {
+	return &VaultProvider{
+		client:   mgr.GetClient(),
+		accounts: kubernetes.NewForConfigOrDie(mgr.GetConfig()).CoreV1(),
+		config:   config,
+		fallback: fallback,
+		logger:   logger,
+		clients:  map[string]*issuerClient{},
+	}
+}
+
+// PKI returns the PKI associated with the given server. This is the shared PKI if the server
+// references an OvpnPKI.
+func (p *VaultProvider) PKI(ctx context.Context, server *api.OvpnServer) (*crypto.PKI, error) {
+	if name := server.Spec.Security.PKIName; name != "" {
+		shared := &api.OvpnPKI{}
+		objectKey := client.ObjectKey{Name: name, Namespace: server.Namespace}
+		if err := p.client.Get(ctx, objectKey, shared); err != nil {
+			return nil, fmt.Errorf("failed to get shared PKI: %s", err)
+		}
+		return p.SharedPKI(ctx, shared)
+	}
+	return p.getPKI(
+		ctx, server.Namespace, server.Name, server.Spec.Security.PKI.IssuerRef,
+	)
+}
+
+// SharedPKI returns the PKI managed by the given OvpnPKI. Its mount path cannot collide with the
+// one of a server as Kubernetes names must not contain underscores.
+func (p *VaultProvider) SharedPKI(ctx context.Context, shared *api.OvpnPKI) (*crypto.PKI, error) {
+	return p.getPKI(
+		ctx, shared.Namespace, fmt.Sprintf("_pki/%s", shared.Name), shared.Spec.IssuerRef,
+	)
+}
+
+func (p *VaultProvider) getPKI(
+	ctx context.Context, namespace, name string, ref *api.VaultIssuerReference,
+) (*crypto.PKI, error) {
+	vault, pkiPath := p.fallback, p.config.PKIPath
+	if ref != nil {
+		issuer, err := p.getIssuerClient(ctx, namespace, *ref)
+		if err != nil {
+			return nil, err
+		}
+		vault = issuer.vault
+		if issuer.pkiPath != "" {
+			pkiPath = issuer.pkiPath
+		}
+	}
+	return crypto.NewPKI(vault, fmt.Sprintf("%s/%s/%s", pkiPath, namespace, name)), nil
+}
+
+func (p *VaultProvider) getIssuerClient(
+	ctx context.Context, namespace string, ref api.VaultIssuerReference,
+) (*issuerClient, error) {
+	// First, we fetch the issuer. Secrets referenced by namespaced issuers must reside in the
+	// namespace of the issuer.
+	var spec api.VaultIssuerSpec
+	var generation int64
+	var key string
+	secretNamespace, accountNamespace := namespace, namespace
+	if ref.Kind == api.VaultIssuerKindCluster {
+		issuer := &api.ClusterVaultIssuer{}
+		if err := p.client.Get(ctx, client.ObjectKey{Name: ref.Name}, issuer); err != nil {
+			return nil, fmt.Errorf("failed to get cluster vault issuer: %w", err)
+		}
+		spec, generation = issuer.Spec, issuer.Generation
+		key = fmt.Sprintf("%s/%s", ref.Kind, ref.Name)
+		if spec.Auth.SecretRef != nil {
+			secretNamespace = p.clusterNamespace(spec.Auth.SecretRef.Namespace)
+		}
+		if spec.Auth.ServiceAccountRef != nil {
+			accountNamespace = p.clusterNamespace(spec.Auth.ServiceAccountRef.Namespace)
+		}
+		if secretNamespace == "" || accountNamespace == "" {
+			return nil, fmt.Errorf(
+				"cluster vault issuer %q does not specify the namespace of its credentials",
+				ref.Name,
+			)
+		}
+	} else {
+		issuer := &api.VaultIssuer{}
+		objectKey := client.ObjectKey{Name: ref.Name, Namespace: namespace}
+		if err := p.client.Get(ctx, objectKey, issuer); err != nil {
+			return nil, fmt.Errorf("failed to get vault issuer: %w", err)
+		}
+		spec, generation = issuer.Spec, issuer.Generation
+		key = fmt.Sprintf("%s/%s/%s", api.VaultIssuerKindNamespaced, namespace, ref.Name)
+	}
+
+	// Then, we read the credentials from the referenced secret
+	config := crypto.VaultConfig{
+		Addr:          spec.Address,
+		CaPEM:         spec.CABundle,
+		ServerName:    spec.ServerName,
+		Namespace:     spec.Namespace,
+		AuthMethod:    string(spec.Auth.Method),
+		AuthMountPath: spec.Auth.MountPath,
+		Role:          spec.Auth.Role,
+		RoleID:        spec.Auth.RoleID,
+	}
+	if config.AuthMethod == "" {
+		config.AuthMethod = crypto.VaultAuthKubernetes
+	}
+	if config.AuthMethod == crypto.VaultAuthKubernetes {
+		// Namespaced issuers are controlled by users of the namespace who must not be able to
+		// obtain the operator's token by pointing the issuer to a Vault instance of their choice
+		account := spec.Auth.ServiceAccountRef
+		if account == nil && ref.Kind != api.VaultIssuerKindCluster {
+			return nil, fmt.Errorf(
+				"vault issuer must reference a service account to use kubernetes auth",
+			)
+		}
+		if account != nil {
+			issuerNamespace := namespace
+			if ref.Kind == api.VaultIssuerKindCluster {
+				issuerNamespace = ""
+			}
+			audiences, err := getAudiences(issuerNamespace, ref.Name, account.Audiences)
+			if err != nil {
+				return nil, err
+			}
+			config.JWTSource = p.tokenSource(accountNamespace, account.Name, audiences)
+		}
+	}
+	version := fmt.Sprintf("%d", generation)
+	if ref := spec.Auth.SecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		objectKey := client.ObjectKey{Name: ref.Name, Namespace: secretNamespace}
+		if err := p.client.Get(ctx, objectKey, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret of vault issuer: %s", err)
+		}
+		switch spec.Auth.Method {
+		case api.VaultAuthMethodAppRole:
+			config.SecretID = string(secret.Data[ref.Key])
+		case api.VaultAuthMethodToken:
+			config.Token = string(secret.Data[ref.Key])
+		}
+		version = fmt.Sprintf("%s/%s", version, secret.ResourceVersion)
+	}
+
+	// Eventually, we can return the cached client or create a new one if the issuer changed
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if cached, ok := p.clients[key]; ok {
+		if cached.version == version {
+			return cached, nil
+		}
+		cached.cancel()
+	}
+
+	authCtx, cancel := context.WithCancel(context.Background())
+	vault, err := crypto.NewVaultClient(authCtx, config, p.logger.With(zap.String("issuer", key)))
+	if err != nil {
+		cancel()
+		delete(p.clients, key)
+		return nil, fmt.Errorf("failed to initialize client for vault issuer: %s", err)
+	}
+	cached := &issuerClient{
+		vault:   vault,
+		pkiPath: spec.PKIPath,
+		version: version,
+		cancel:  cancel,
+	}
+	p.clients[key] = cached
+	return cached, nil
+}
+
+// clusterNamespace returns the given namespace of an object referenced by a cluster issuer or the
+// operator's namespace if it is empty.
+func (p *VaultProvider) clusterNamespace(namespace string) string {
+	if namespace == "" {
+		return p.config.Namespace
+	}
+	return namespace
+}
+
+// getAudiences returns the audiences of tokens requested for the issuer with the given namespace
+// and name. Unless audiences are configured explicitly, the tokens are only valid for the issuer.
+// Audiences of the Kubernetes API are rejected as the tokens are sent to the issuer's address,
+// which is chosen by the issuer's author.
+func getAudiences(namespace, name string, audiences []string) ([]string, error) {
+	if len(audiences) == 0 {
+		if namespace == "" {
+			return []string{serviceAccountAudiencePrefix + name}, nil
+		}
+		return []string{fmt.Sprintf("%s%s/%s", serviceAccountAudiencePrefix, namespace, name)}, nil
+	}
+	for _, audience := range audiences {
+		if !strings.HasPrefix(audience, serviceAccountAudiencePrefix) {
+			return nil, fmt.Errorf(
+				"audience %q of vault issuer does not start with %q",
+				audience, serviceAccountAudiencePrefix,
+			)
+		}
+	}
+	return audiences, nil
+}
+
+// tokenSource returns a function which requests a short-lived token with the given audiences for
+// the service account with the given namespace and name. The operator may only request tokens
+// for service accounts which grant it `create` on their `serviceaccounts/token` subresource.
+func (p *VaultProvider) tokenSource(
+	namespace, name string, audiences []string,
+) func() (string, error) {
+	return func() (string, error) {
+		expiration := int64(serviceAccountTokenExpiration.Seconds())
+		request := &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{
+				Audiences:         audiences,
+				ExpirationSeconds: &expiration,
+			},
+		}
+		response, err := p.accounts.ServiceAccounts(namespace).CreateToken(
+			context.Background(), name, request, metav1.CreateOptions{},
+		)
+		if apierrors.IsForbidden(err) {
+			return "", fmt.Errorf(
+				"service account %s/%s does not permit the operator to request tokens: %s",
+				namespace, name, err,
+			)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to request service account token: %s", err)
+		}
+		return response.Status.Token, nil
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// issuerKey identifies a VaultIssuer or a ClusterVaultIssuer. The namespace of cluster issuers is
+// empty.
+type issuerKey struct {
+	kind      api.VaultIssuerKind
+	namespace string
+	name      string
+}
+
+// referencedBy returns whether the given reference of an object in the given namespace refers to
+// the issuer.
+func (k issuerKey) referencedBy(namespace string, ref *api.VaultIssuerReference) bool {
+	if ref == nil || ref.Name != k.name {
+		return false
+	}
+	if ref.Kind == api.VaultIssuerKindCluster {
+		return k.kind == api.VaultIssuerKindCluster
+	}
+	return k.kind == api.VaultIssuerKindNamespaced && k.namespace == namespace
+}
+
+// affectedIssuers returns the issuers whose connections change along with the given object. The
+// object is either an issuer itself or a secret holding the credentials of issuers.
+func (p *VaultProvider) affectedIssuers(obj client.Object) []issuerKey {
+	switch obj.(type) {
+	case *api.VaultIssuer:
+		return []issuerKey{{
+			kind: api.VaultIssuerKindNamespaced, namespace: obj.GetNamespace(), name: obj.GetName(),
+		}}
+	case *api.ClusterVaultIssuer:
+		return []issuerKey{{kind: api.VaultIssuerKindCluster, name: obj.GetName()}}
+	case *corev1.Secret:
+	default:
+		return nil
+	}
+
+	// For secrets, we need to find all issuers referencing them
+	ctx := context.Background()
+	issuers := []issuerKey{}
+	namespaced := &api.VaultIssuerList{}
+	if err := p.client.List(ctx, namespaced, client.InNamespace(obj.GetNamespace())); err != nil {
+		p.logger.Error("failed to list vault issuers", zap.Error(err))
+		return nil
+	}
+	for _, issuer := range namespaced.Items {
+		if ref := issuer.Spec.Auth.SecretRef; ref != nil && ref.Name == obj.GetName() {
+			issuers = append(issuers, issuerKey{
+				kind:      api.VaultIssuerKindNamespaced,
+				namespace: issuer.Namespace,
+				name:      issuer.Name,
+			})
+		}
+	}
+	cluster := &api.ClusterVaultIssuerList{}
+	if err := p.client.List(ctx, cluster); err != nil {
+		p.logger.Error("failed to list cluster vault issuers", zap.Error(err))
+		return nil
+	}
+	for _, issuer := range cluster.Items {
+		ref := issuer.Spec.Auth.SecretRef
+		if ref != nil && ref.Name == obj.GetName() &&
+			p.clusterNamespace(ref.Namespace) == obj.GetNamespace() {
+			issuers = append(issuers, issuerKey{
+				kind: api.VaultIssuerKindCluster,
+				name: issuer.Name,
+			})
+		}
+	}
+	return issuers
+}
+
+// referencesAny returns whether the given reference of an object in the given namespace refers to
+// any of the given issuers.
+func referencesAny(issuers []issuerKey, namespace string, ref *api.VaultIssuerReference) bool {
+	for _, issuer := range issuers {
+		if issuer.referencedBy(namespace, ref) {
+			return true
+		}
+	}
+	return false
+}
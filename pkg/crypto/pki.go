@@ -3,7 +3,10 @@ package crypto
 import (
 	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	vaultapi "github.com/hashicorp/vault/api"
@@ -21,6 +24,7 @@ type PKICertificate struct {
 	Certificate   string
 	PrivateKey    string
 	CACertificate string
+	NotBefore     time.Time
 	Expiration    time.Time
 }
 
@@ -158,13 +162,30 @@ func (pki *PKI) ConfigureRole(name string, config PKIRoleConfig) error {
 }
 
 // Generate generates a new certificate for the provided role with the given common name. If the
-// validity is greater than 0, it replaces the default validity.
-func (pki *PKI) Generate(role, commonName string, validity time.Duration) (PKICertificate, error) {
+// validity is greater than 0, it replaces the default validity. Alternative names may either be
+// hostnames or IPs.
+func (pki *PKI) Generate(
+	role, commonName string, validity time.Duration, altNames ...string,
+) (PKICertificate, error) {
 	path := fmt.Sprintf("%s/issue/%s", pki.path, role)
 	contents := map[string]interface{}{
 		"common_name": commonName,
 		"format":      "pem",
 	}
+	dnsNames, ipSans := []string{}, []string{}
+	for _, name := range altNames {
+		if net.ParseIP(name) != nil {
+			ipSans = append(ipSans, name)
+		} else {
+			dnsNames = append(dnsNames, name)
+		}
+	}
+	if len(dnsNames) > 0 {
+		contents["alt_names"] = strings.Join(dnsNames, ",")
+	}
+	if len(ipSans) > 0 {
+		contents["ip_sans"] = strings.Join(ipSans, ",")
+	}
 	if validity > 0 {
 		contents["ttl"] = fmt.Sprintf("%ds", int(validity.Seconds()))
 	}
@@ -176,16 +197,38 @@ func (pki *PKI) Generate(role, commonName string, validity time.Duration) (PKICe
 	if err != nil {
 		return PKICertificate{}, fmt.Errorf("invalid expiration date: %s", err)
 	}
+	certificate := result.Data["certificate"].(string)
+	notBefore, err := pki.certificateNotBefore(certificate)
+	if err != nil {
+		return PKICertificate{}, err
+	}
 
 	return PKICertificate{
 		Serial:        result.Data["serial_number"].(string),
-		Certificate:   result.Data["certificate"].(string),
+		Certificate:   certificate,
 		PrivateKey:    result.Data["private_key"].(string),
 		CACertificate: result.Data["issuing_ca"].(string),
+		NotBefore:     notBefore,
 		Expiration:    time.Unix(expiration, 0),
 	}, nil
 }
 
+// CACertificate returns the PEM-encoded root certificate of the PKI.
+func (pki *PKI) CACertificate() (string, error) {
+	result, err := pki.client.Logical().Read(fmt.Sprintf("%s/cert/ca", pki.path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read CA certificate: %s", err)
+	}
+	if result == nil {
+		return "", fmt.Errorf("CA certificate does not exist")
+	}
+	certificate, ok := result.Data["certificate"].(string)
+	if !ok {
+		return "", fmt.Errorf("CA certificate is invalid")
+	}
+	return certificate, nil
+}
+
 // Revoke revokes the certificate with the given serial.
 func (pki *PKI) Revoke(serial string) error {
 	path := fmt.Sprintf("%s/revoke", pki.path)
@@ -237,6 +280,18 @@ func (pki *PKI) readCRL() (string, error) {
 	return result.Data["certificate"].(string), nil
 }
 
+func (pki *PKI) certificateNotBefore(certificate string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil {
+		return time.Time{}, fmt.Errorf("failed to decode certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse certificate: %s", err)
+	}
+	return cert.NotBefore, nil
+}
+
 func (pki *PKI) crlExpiration(crl string) (time.Time, error) {
 	cert, err := x509.ParseCRL([]byte(crl))
 	if err != nil {
@@ -2,7 +2,10 @@ package crypto
 
 import (
 	"context"
+	"crypto/x509"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -12,12 +15,110 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// VaultAuthFile reads the token from a file, typically written by a Vault agent.
+	VaultAuthFile = "file"
+	// VaultAuthToken uses a static token.
+	VaultAuthToken = "token"
+	// VaultAuthKubernetes logs in using the token of the pod's service account.
+	VaultAuthKubernetes = "kubernetes"
+	// VaultAuthAppRole logs in using a role ID and a secret ID.
+	VaultAuthAppRole = "approle"
+)
+
 // VaultConfig describes the configuration for a Vault instance.
 type VaultConfig struct {
 	Addr       string `required:"true"`
-	TokenMount string `required:"true" split_words:"true"`
 	CaCrt      string `split_words:"true"`
 	ServerName string `split_words:"true"`
+	// The PEM-encoded CA certificate, used instead of `CaCrt` if set. Can only be set
+	// programmatically.
+	CaPEM string `ignored:"true"`
+	// The Vault Enterprise namespace to use.
+	Namespace string
+	// The method used to authenticate against Vault.
+	AuthMethod string `split_words:"true" default:"file"`
+	// The path at which the auth method is mounted. Defaults to `auth/<method>`.
+	AuthMountPath string `split_words:"true"`
+	// The file containing the token for the file method.
+	TokenMount string `split_words:"true"`
+	// The token for the token method.
+	Token string
+	// The role to log in with for the Kubernetes method.
+	Role string
+	// The file containing the service account token for the Kubernetes method. Defaults to the
+	// token mounted into the pod.
+	JWTPath string `split_words:"true"`
+	// Requests the service account token for the Kubernetes method, used instead of `JWTPath` if
+	// set. Can only be set programmatically.
+	JWTSource func() (string, error) `ignored:"true"`
+	// The role ID and secret ID for the AppRole method.
+	RoleID   string `split_words:"true"`
+	SecretID string `split_words:"true"`
+}
+
+// NewVaultClient initializes a new client for the given Vault instance. Authentication is handled
+// in the background until the given context is cancelled: tokens are renewed as long as their
+// lease permits and the client logs in again whenever Vault rejects a request with a 403.
+func NewVaultClient(
+	ctx context.Context, config VaultConfig, logger *zap.Logger,
+) (*vaultapi.Client, error) {
+	// First, we validate the authentication config
+	switch config.AuthMethod {
+	case VaultAuthFile:
+		if config.TokenMount == "" {
+			return nil, fmt.Errorf("token mount must be set for auth method %q", config.AuthMethod)
+		}
+	case VaultAuthToken:
+		if config.Token == "" {
+			return nil, fmt.Errorf("token must be set for auth method %q", config.AuthMethod)
+		}
+	case VaultAuthKubernetes:
+		if config.Role == "" {
+			return nil, fmt.Errorf("role must be set for auth method %q", config.AuthMethod)
+		}
+	case VaultAuthAppRole:
+		if config.RoleID == "" {
+			return nil, fmt.Errorf("role ID must be set for auth method %q", config.AuthMethod)
+		}
+	default:
+		return nil, fmt.Errorf("unknown auth method %q", config.AuthMethod)
+	}
+
+	// Then, we can setup the client
+	apiConfig := vaultapi.DefaultConfig()
+	apiConfig.Address = config.Addr
+	if err := apiConfig.ConfigureTLS(&vaultapi.TLSConfig{
+		CACert:        config.CaCrt,
+		TLSServerName: config.ServerName,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %s", err)
+	}
+	if config.CaPEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CaPEM)) {
+			return nil, fmt.Errorf("failed to parse CA certificate")
+		}
+		transport := apiConfig.HttpClient.Transport.(*http.Transport)
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	client, err := vaultapi.NewClient(apiConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize client: %s", err)
+	}
+	if config.Namespace != "" {
+		client.SetNamespace(config.Namespace)
+	}
+
+	// And eventually, we keep it authenticated. Rejected requests are observed on the transport
+	// such that we don't need to check for them at every call site.
+	auth := newVaultAuthenticator(client, config, logger)
+	apiConfig.HttpClient.Transport = &forbiddenTransport{
+		next:   apiConfig.HttpClient.Transport,
+		notify: auth.invalidate,
+	}
+	go auth.run(ctx)
+	return client, nil
 }
 
 // EnsureTokenUpdated enters an infinite loop that watches the given target file and updates the
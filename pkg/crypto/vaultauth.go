@@ -0,0 +1,258 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultJWTPath  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	loginBackoffMin = time.Second
+	loginBackoffMax = 5 * time.Minute
+	// Permission errors shortly after a login are most likely caused by missing policies and not
+	// by an invalid token, we therefore don't log in again for them.
+	reloginGracePeriod = time.Minute
+)
+
+type vaultAuthenticator struct {
+	client      *vaultapi.Client
+	config      VaultConfig
+	logger      *zap.Logger
+	invalidated chan struct{}
+}
+
+func newVaultAuthenticator(
+	client *vaultapi.Client, config VaultConfig, logger *zap.Logger,
+) *vaultAuthenticator {
+	return &vaultAuthenticator{
+		client:      client,
+		config:      config,
+		logger:      logger,
+		invalidated: make(chan struct{}, 1),
+	}
+}
+
+// invalidate signals that Vault rejected the client's token. It never blocks.
+func (a *vaultAuthenticator) invalidate() {
+	select {
+	case a.invalidated <- struct{}{}:
+	default:
+	}
+}
+
+func (a *vaultAuthenticator) run(ctx context.Context) {
+	switch a.config.AuthMethod {
+	case VaultAuthFile:
+		EnsureTokenUpdated(ctx, a.client, a.config.TokenMount, a.logger)
+	case VaultAuthToken:
+		a.runStatic(ctx)
+	default:
+		a.runLogin(ctx)
+	}
+}
+
+// runStatic uses the configured token and renews it for as long as Vault permits.
+func (a *vaultAuthenticator) runStatic(ctx context.Context) {
+	a.client.SetToken(a.config.Token)
+
+	secret, err := a.client.Auth().Token().LookupSelf()
+	if err != nil {
+		a.logger.Error("failed to look up static token", zap.Error(err))
+		return
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil || !renewable {
+		a.logger.Info("static token is not renewable")
+		return
+	}
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		a.logger.Error("failed to read TTL of static token", zap.Error(err))
+		return
+	}
+
+	a.keepRenewed(ctx, &vaultapi.Secret{
+		Auth: &vaultapi.SecretAuth{
+			ClientToken:   a.config.Token,
+			Renewable:     true,
+			LeaseDuration: int(ttl.Seconds()),
+		},
+	})
+	a.logger.Warn("static token can no longer be renewed, requests may start failing")
+}
+
+// runLogin repeatedly logs in via the configured auth method and keeps the obtained token renewed
+// until it either expires or is rejected by Vault.
+func (a *vaultAuthenticator) runLogin(ctx context.Context) {
+	backoff := loginBackoffMin
+	for {
+		// Any pending invalidation is obsolete as we are about to obtain a new token anyway
+		select {
+		case <-a.invalidated:
+		default:
+		}
+
+		secret, err := a.login()
+		if err != nil {
+			a.logger.Error(
+				"failed to log in, retrying", zap.Error(err), zap.Duration("backoff", backoff),
+			)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > loginBackoffMax {
+				backoff = loginBackoffMax
+			}
+			continue
+		}
+		backoff = loginBackoffMin
+
+		a.client.SetToken(secret.Auth.ClientToken)
+		a.logger.Info(
+			"successfully logged in",
+			zap.Duration("lease", time.Duration(secret.Auth.LeaseDuration)*time.Second),
+		)
+		if !a.keepRenewed(ctx, secret) {
+			return
+		}
+	}
+}
+
+func (a *vaultAuthenticator) login() (*vaultapi.Secret, error) {
+	var data map[string]interface{}
+	switch a.config.AuthMethod {
+	case VaultAuthKubernetes:
+		jwt, err := a.serviceAccountToken()
+		if err != nil {
+			return nil, err
+		}
+		data = map[string]interface{}{
+			"role": a.config.Role,
+			"jwt":  jwt,
+		}
+	case VaultAuthAppRole:
+		data = map[string]interface{}{
+			"role_id":   a.config.RoleID,
+			"secret_id": a.config.SecretID,
+		}
+	}
+
+	// The login must not carry an expired token
+	client, err := a.client.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone client: %s", err)
+	}
+	client.ClearToken()
+
+	mountPath := a.config.AuthMountPath
+	if mountPath == "" {
+		mountPath = fmt.Sprintf("auth/%s", a.config.AuthMethod)
+	}
+	secret, err := client.Logical().Write(
+		fmt.Sprintf("%s/login", strings.Trim(mountPath, "/")), data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Auth == nil {
+		return nil, fmt.Errorf("login did not return a token")
+	}
+	return secret, nil
+}
+
+// serviceAccountToken returns the token to log in with for the Kubernetes method. It is either
+// requested from the configured source or read from the configured file.
+func (a *vaultAuthenticator) serviceAccountToken() (string, error) {
+	if a.config.JWTSource != nil {
+		return a.config.JWTSource()
+	}
+	jwtPath := a.config.JWTPath
+	if jwtPath == "" {
+		jwtPath = defaultJWTPath
+	}
+	jwt, err := ioutil.ReadFile(jwtPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read service account token: %s", err)
+	}
+	return strings.TrimSpace(string(jwt)), nil
+}
+
+// keepRenewed renews the token of the given secret until its lease can no longer be extended or
+// the token is rejected by Vault. Returns false if the context was cancelled.
+func (a *vaultAuthenticator) keepRenewed(ctx context.Context, secret *vaultapi.Secret) bool {
+	issued := time.Now()
+
+	if !secret.Auth.Renewable {
+		// Non-renewable tokens are replaced well before they expire
+		lease := time.Duration(secret.Auth.LeaseDuration) * time.Second
+		expiry := time.After(lease * 2 / 3)
+		for {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-expiry:
+				return true
+			case <-a.invalidated:
+				if time.Since(issued) > reloginGracePeriod {
+					a.logger.Warn("token was rejected, logging in again")
+					return true
+				}
+			}
+		}
+	}
+
+	renewer, err := a.client.NewRenewer(&vaultapi.RenewerInput{Secret: secret})
+	if err != nil {
+		a.logger.Error("failed to initialize token renewer", zap.Error(err))
+		return true
+	}
+	go renewer.Renew()
+	defer renewer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case err := <-renewer.DoneCh():
+			if err != nil {
+				a.logger.Warn("failed to renew token", zap.Error(err))
+			} else {
+				a.logger.Info("token reached its maximum lease")
+			}
+			return true
+		case renewal := <-renewer.RenewCh():
+			a.logger.Debug("successfully renewed token", zap.Time("renewed_at", renewal.RenewedAt))
+		case <-a.invalidated:
+			if time.Since(issued) > reloginGracePeriod {
+				a.logger.Warn("token was rejected, logging in again")
+				return true
+			}
+		}
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// forbiddenTransport notifies about every request that Vault rejects with a 403, which usually
+// means that the token expired or has been revoked.
+type forbiddenTransport struct {
+	next   http.RoundTripper
+	notify func()
+}
+
+func (t *forbiddenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusForbidden {
+		t.notify()
+	}
+	return resp, err
+}
@@ -0,0 +1,25 @@
+package ovpn
+
+import (
+	"strings"
+
+	"github.com/borchero/meerkat-operator/pkg/ovpn/static"
+)
+
+// ClientConfigValues describes the set of values required to render the server-side config of a
+// client acting as a gateway.
+type ClientConfigValues struct {
+	Subnets    []ConfigRoute
+	Advertised bool
+}
+
+// GetClientConfig returns the config that the server reads when the client connects. It routes
+// the networks behind the client to the client and, if the networks are advertised to all
+// clients, prevents the routes from being pushed to the client itself.
+func GetClientConfig(values ClientConfigValues) (string, error) {
+	config, err := renderTemplate("ccd", static.TemplateClientConfig, values)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(config, "\n\t\r "), nil
+}
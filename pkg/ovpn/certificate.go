@@ -8,11 +8,18 @@ import (
 
 // CertificateValues describes the set of values required to render OVPN certificates.
 type CertificateValues struct {
-	Secrets  CertificateSecrets
+	Secrets           CertificateSecrets
+	Remotes           []CertificateRemote
+	RemoteRandom      bool
+	ServerPollTimeout int
+	Security          ConfigSecurity
+}
+
+// CertificateRemote describes a server that a client may connect to.
+type CertificateRemote struct {
 	Host     string
 	Port     uint16
 	Protocol string
-	Security ConfigSecurity
 }
 
 // CertificateSecrets contains all relevant secrets for generating an OVPN client file.
@@ -8,12 +8,37 @@ import (
 
 // ConfigValues describes the set of values required to render the OVPN config file.
 type ConfigValues struct {
-	Files       ConfigFiles
-	Routes      []ConfigRoute
-	Nameservers []string
-	RedirectAll bool
-	Protocol    string
-	Security    ConfigSecurity
+	Files           ConfigFiles
+	Routes          []ConfigRoute
+	ExcludedRoutes  []ConfigRoute
+	GatewayRoutes   []ConfigRoute
+	Nameservers     []string
+	DNS             ConfigDNS
+	RedirectAll     bool
+	RedirectFlags   []string
+	IPv6            bool
+	ClientToClient  bool
+	Protocol        string
+	Port            int
+	Device          string
+	ManagementPort  int
+	StatusFile      string
+	ClientConfigDir string
+	PortShare       *ConfigPortShare
+	Security        ConfigSecurity
+}
+
+// ConfigDNS describes the DNS settings pushed to clients besides the nameservers.
+type ConfigDNS struct {
+	SearchDomains   []string
+	SplitDomains    []string
+	BlockOutsideDNS bool
+}
+
+// ConfigPortShare describes the server receiving non-OpenVPN traffic on a TCP port.
+type ConfigPortShare struct {
+	Host string
+	Port int
 }
 
 // ConfigFiles describes the set of file paths required for the OVPN config file.
@@ -24,12 +49,16 @@ type ConfigFiles struct {
 	DHParams     string
 	TLSAuth      string
 	CRL          string
+	VerifyClient string
 }
 
-// ConfigRoute describes a route for the OVPN config file, consisting of IP and subnet mask.
+// ConfigRoute describes a route for the OVPN config file, consisting of IP and subnet mask. For
+// IPv6 routes, the mask is the length of the prefix. The metric is optional.
 type ConfigRoute struct {
-	IP   string
-	Mask string
+	IP     string
+	Mask   string
+	IPv6   bool
+	Metric string
 }
 
 // ConfigSecurity describe the security configuration for the OVPN config file.
@@ -7,26 +7,35 @@ import (
 	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
 )
 
-// ParseRoutes is a utility function to convert the api's subnet masks into routes for the OVPN
+// ParseRoutes is a utility function to convert the api's routes into routes for the OVPN config
+// file. IPv6 routes retain the length of their prefix.
+func ParseRoutes(routes []api.OvpnRoute) []ConfigRoute {
+	result := make([]ConfigRoute, len(routes))
+	for i, route := range routes {
+		result[i] = parseSubnet(route.Network)
+		if route.Metric != nil {
+			result[i].Metric = strconv.Itoa(int(*route.Metric))
+		}
+	}
+	return result
+}
+
+// ParseSubnets is a utility function to convert the api's subnet masks into routes for the OVPN
 // config file.
-func ParseRoutes(subnets []api.SubnetMask) []ConfigRoute {
+func ParseSubnets(subnets []api.SubnetMask) []ConfigRoute {
 	result := make([]ConfigRoute, len(subnets))
 	for i, subnet := range subnets {
-		splits := strings.Split(string(subnet), "/")
-		result[i].IP = splits[0]
-		result[i].Mask = getMask(splits[1])
+		result[i] = parseSubnet(subnet)
 	}
 	return result
 }
 
-// ParseRoutesString is a utility function to convert the api's subnet masks into iptable routes.
-func ParseRoutesString(subnets []api.SubnetMask) []string {
-	result := make([]string, len(subnets))
-	for i, subnet := range subnets {
-		splits := strings.Split(string(subnet), "/")
-		result[i] = splits[0] + "/" + getMask(splits[1])
+func parseSubnet(subnet api.SubnetMask) ConfigRoute {
+	splits := strings.Split(string(subnet), "/")
+	if strings.Contains(splits[0], ":") {
+		return ConfigRoute{IP: splits[0], Mask: splits[1], IPv6: true}
 	}
-	return result
+	return ConfigRoute{IP: splits[0], Mask: getMask(splits[1])}
 }
 
 func getMask(stringSize string) string {
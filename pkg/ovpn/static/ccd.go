@@ -0,0 +1,21 @@
+package static
+
+// TemplateClientConfig contains the template for the server-side config of a single client.
+const TemplateClientConfig = `
+{{ range .Subnets -}}
+{{ if .IPv6 -}}
+iroute-ipv6 {{ .IP }}/{{ .Mask }}
+{{ else -}}
+iroute {{ .IP }} {{ .Mask }}
+{{ end -}}
+{{ end -}}
+{{ if .Advertised -}}
+{{ range .Subnets -}}
+{{ if .IPv6 -}}
+push-remove "route-ipv6 {{ .IP }}/{{ .Mask }}"
+{{ else -}}
+push-remove "route {{ .IP }} {{ .Mask }}"
+{{ end -}}
+{{ end -}}
+{{ end -}}
+`
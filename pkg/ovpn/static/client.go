@@ -6,7 +6,15 @@ client
 nobind
 dev tun
 remote-cert-tls server
+{{- range .Remotes }}
 remote {{ .Host }} {{ .Port }} {{ .Protocol | lower }}
+{{- end }}
+{{- if .RemoteRandom }}
+remote-random
+{{- end }}
+{{- if .ServerPollTimeout }}
+server-poll-timeout {{ .ServerPollTimeout }}
+{{- end }}
 
 <key>
 {{ .Secrets.TLSClientKey | trim }}
@@ -5,15 +5,18 @@ const TemplateConfig = `
 user nobody
 group nogroup
 
-status /tmp/openvpn.log
+status {{ .StatusFile }}
+management 127.0.0.1 {{ .ManagementPort }}
 {{ if eq .Protocol "UDP" -}}
 explicit-exit-notify 1
 {{ end -}}
 
-server 192.168.255.0 255.255.255.0
-port 1194
-proto {{ .Protocol | lower }}
-dev tun0
+port {{ .Port }}
+proto {{ .Protocol | lower }}{{ if .IPv6 }}6{{ end }}
+dev {{ .Device }}
+{{- if .PortShare }}
+port-share {{ .PortShare.Host }} {{ .PortShare.Port }}
+{{- end }}
 
 cert {{ .Files.TLSServerCrt }}
 key {{ .Files.TLSServerKey }}
@@ -21,6 +24,7 @@ ca {{ .Files.TLSCaCrt }}
 dh {{ .Files.DHParams }}
 tls-crypt {{ .Files.TLSAuth }}
 crl-verify {{ .Files.CRL }}
+tls-verify {{ .Files.VerifyClient }}
 
 auth {{ .Security.Hmac }}
 cipher {{ .Security.Cipher }}
@@ -29,16 +33,46 @@ keepalive 10 60
 key-direction 0
 persist-key
 persist-tun
+script-security 2
 verb 3
+client-config-dir {{ .ClientConfigDir }}
+{{- if .ClientToClient }}
+client-to-client
+{{- end }}
 
-push "route 192.168.255.0 255.255.255.0"
+{{ range .GatewayRoutes -}}
+{{ if .IPv6 -}}
+route-ipv6 {{ .IP }}/{{ .Mask }}
+{{ else -}}
+route {{ .IP }} {{ .Mask }}
+{{ end -}}
+{{ end -}}
 {{ range .Routes -}}
-push "route {{ .IP }} {{ .Mask }}"
+{{ if .IPv6 -}}
+push "route-ipv6 {{ .IP }}/{{ .Mask }}{{ with .Metric }} default {{ . }}{{ end }}"
+{{ else -}}
+push "route {{ .IP }} {{ .Mask }}{{ with .Metric }} vpn_gateway {{ . }}{{ end }}"
+{{ end -}}
+{{ end -}}
+{{ range .ExcludedRoutes -}}
+push "route {{ .IP }} {{ .Mask }} net_gateway"
 {{ end -}}
 {{ range .Nameservers -}}
-push "dhcp-option DNS {{ . }}"
+push "dhcp-option {{ if contains ":" . }}DNS6{{ else }}DNS{{ end }} {{ . }}"
+{{ end -}}
+{{ with .DNS.SearchDomains -}}
+push "dhcp-option DOMAIN {{ first . }}"
+{{ range . -}}
+push "dhcp-option DOMAIN-SEARCH {{ . }}"
+{{ end -}}
+{{ end -}}
+{{ range .DNS.SplitDomains -}}
+push "dhcp-option DOMAIN-ROUTE {{ . }}"
+{{ end -}}
+{{ if .DNS.BlockOutsideDNS -}}
+push "block-outside-dns"
 {{ end -}}
 {{ if .RedirectAll -}}
-push "redirect-gateway def1"
+push "redirect-gateway {{ join " " .RedirectFlags }}{{ if .IPv6 }} ipv6{{ end }}"
 {{ end -}}
 `
@@ -0,0 +1,29 @@
+package static
+
+// TemplateStunnel contains the template for stunnel config files of both servers and clients.
+const TemplateStunnel = `
+{{- if .Client }}
+client = yes
+{{- else }}
+foreground = yes
+pid =
+{{- end }}
+{{ range .Services }}
+[{{ .Name }}]
+accept = {{ .Accept }}
+{{- range .Connect }}
+connect = {{ . }}
+{{- end }}
+{{- if gt (len .Connect) 1 }}
+failover = prio
+{{- end }}
+{{- if .Cert }}
+cert = {{ .Cert }}
+key = {{ .Key }}
+{{- end }}
+{{- if .CAFile }}
+CAfile = {{ .CAFile }}
+verifyChain = yes
+{{- end }}
+{{ end }}
+`
@@ -0,0 +1,17 @@
+package static
+
+// TemplateVerify contains the template for the script verifying client certificates.
+const TemplateVerify = `
+#!/bin/sh
+
+# Only the client's own certificate (at depth 0) is of interest
+if [ "$1" -ne 0 ]; then
+    exit 0
+fi
+
+# Reject all clients which are currently suspended
+if grep -qxF "$X509_0_CN" {{ .SuspendedClients }}; then
+    echo "rejecting suspended client $X509_0_CN"
+    exit 1
+fi
+`
@@ -0,0 +1,34 @@
+package ovpn
+
+import (
+	"strings"
+
+	"github.com/borchero/meerkat-operator/pkg/ovpn/static"
+)
+
+// StunnelValues describes the set of values required to render a stunnel config file.
+type StunnelValues struct {
+	Client   bool
+	Services []StunnelService
+}
+
+// StunnelService describes a single service of stunnel, accepting connections at one address and
+// forwarding them to (one of) the others.
+type StunnelService struct {
+	Name    string
+	Accept  string
+	Connect []string
+	Cert    string
+	Key     string
+	CAFile  string
+}
+
+// GetStunnelConfig returns the stunnel config for the given values. Servers use it to terminate
+// TLS in front of OpenVPN while clients use it to connect to such servers.
+func GetStunnelConfig(values StunnelValues) (string, error) {
+	config, err := renderTemplate("stunnel", static.TemplateStunnel, values)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(config, "\n\t\r "), nil
+}
@@ -0,0 +1,22 @@
+package ovpn
+
+import (
+	"strings"
+
+	"github.com/borchero/meerkat-operator/pkg/ovpn/static"
+)
+
+// VerifyValues describes the set of values required to render the script verifying clients.
+type VerifyValues struct {
+	SuspendedClients string
+}
+
+// GetVerifyScript returns the script that is run by the server to verify clients' certificates.
+// It rejects all clients which have been suspended.
+func GetVerifyScript(values VerifyValues) (string, error) {
+	script, err := renderTemplate("verify", static.TemplateVerify, values)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(script, "\n\t\r "), nil
+}
@@ -0,0 +1,73 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeClientConfigs writes the server-side configs of all clients into the client config
+// directory where each file is named after the common name of its client. Files of clients which
+// are no longer listed are removed. It returns the number of files that changed.
+func writeClientConfigs(source, dir string) (int, error) {
+	// First, we read the configs...
+	data, err := ioutil.ReadFile(source)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read client configs: %s", err)
+	}
+	configs := map[string]string{}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &configs); err != nil {
+			return 0, fmt.Errorf("failed to parse client configs: %s", err)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create client config directory: %s", err)
+	}
+
+	// ... then write the ones that changed. Files are replaced atomically as servers may read
+	// them at any time.
+	changed := 0
+	for name, config := range configs {
+		if !isValidFileName(name) {
+			return changed, fmt.Errorf("common name %q cannot be used as file name", name)
+		}
+		path := filepath.Join(dir, name)
+		if current, err := ioutil.ReadFile(path); err == nil && string(current) == config {
+			continue
+		}
+		tmp := filepath.Join(dir, "."+name+".tmp")
+		if err := ioutil.WriteFile(tmp, []byte(config), 0644); err != nil {
+			return changed, fmt.Errorf("failed to write config of client %q: %s", name, err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return changed, fmt.Errorf("failed to write config of client %q: %s", name, err)
+		}
+		changed++
+	}
+
+	// Eventually, we remove the configs of all other clients
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return changed, fmt.Errorf("failed to list client configs: %s", err)
+	}
+	for _, file := range files {
+		if _, ok := configs[file.Name()]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, file.Name())); err != nil {
+			return changed, fmt.Errorf("failed to remove config of client %q: %s", file.Name(), err)
+		}
+		changed++
+	}
+	return changed, nil
+}
+
+// isValidFileName returns whether the given common name can be used as the name of a file within
+// the client config directory.
+func isValidFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\x00")
+}
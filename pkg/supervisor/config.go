@@ -0,0 +1,132 @@
+package supervisor
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config describes the configuration of the supervisor running the OpenVPN processes of a server.
+// It is rendered by the operator and read by the supervisor on startup.
+type Config struct {
+	// The OpenVPN processes to run.
+	Servers []ServerConfig `json:"servers"`
+	// The prefix of the addresses of all pools, i.e. the first two octets.
+	PoolPrefix string `json:"poolPrefix"`
+	// The subnet mask of the address pool of each process.
+	SubnetMask string `json:"subnetMask"`
+	// The IPv6 configuration. If nil, clients are only assigned IPv4 addresses.
+	IPv6 *IPv6Config `json:"ipv6,omitempty"`
+	// The configuration of how client traffic leaves the pod.
+	Egress EgressConfig `json:"egress"`
+	// Additional IPv4 and IPv6 networks in CIDR notation whose traffic is translated as well.
+	Routes []string `json:"routes,omitempty"`
+	// Networks behind clients acting as gateways. Traffic from them is translated like traffic
+	// from the address pools while traffic to them is never translated.
+	GatewaySubnets []string `json:"gatewaySubnets,omitempty"`
+	// The path of the file listing the common names of suspended clients.
+	SuspendedClients string `json:"suspendedClients"`
+	// The path of the JSON file mapping the common names of clients to their server-side configs.
+	ClientConfigs string `json:"clientConfigs"`
+	// The directory that the servers read the configs of connecting clients from.
+	ClientConfigDir string `json:"clientConfigDir"`
+	// Files which cause all processes to be reloaded if their contents change.
+	ReloadFiles []string `json:"reloadFiles,omitempty"`
+	// The port at which the health endpoints are exposed.
+	HealthPort int `json:"healthPort"`
+}
+
+// ServerConfig describes a single OpenVPN process. Its address pools are derived from the ordinal
+// of the pod.
+type ServerConfig struct {
+	Name           string `json:"name"`
+	Config         string `json:"config"`
+	PoolOctet      int    `json:"poolOctet"`
+	PoolIndex      int    `json:"poolIndex"`
+	ManagementPort int    `json:"managementPort"`
+}
+
+// IPv6Config describes the IPv6 address pools and how IPv6 traffic leaves the pod.
+type IPv6Config struct {
+	// The range in CIDR notation which the /64 pools of all processes are allocated from.
+	Pool string `json:"pool"`
+	// Whether traffic is translated (`NAT`) or forwarded as is (`Routed`).
+	Mode string `json:"mode"`
+}
+
+// EgressConfig describes how client traffic leaves the pod.
+type EgressConfig struct {
+	// The interface that traffic leaves through or `auto` for the interface of the default route.
+	Interface string `json:"interface"`
+	// The firewall backend, one of `iptables-legacy`, `iptables-nft` and `nftables`.
+	Backend string `json:"backend"`
+	// The source address of traffic. If empty, traffic is masqueraded.
+	SNATAddress string `json:"snatAddress,omitempty"`
+}
+
+// LoadConfig reads the supervisor configuration from the file at the given path.
+func LoadConfig(path string) (Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %s", err)
+	}
+	config := Config{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %s", err)
+	}
+	if len(config.Servers) == 0 {
+		return Config{}, fmt.Errorf("config does not define any servers")
+	}
+	return config, nil
+}
+
+// Encode returns the JSON representation of the configuration.
+func (c Config) Encode() (string, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %s", err)
+	}
+	return string(data), nil
+}
+
+// Subnet returns the address pool of the given server for the replica with the given ordinal.
+func (c Config) Subnet(server ServerConfig, ordinal int) string {
+	return fmt.Sprintf("%s.%d.0", c.PoolPrefix, server.PoolOctet-ordinal)
+}
+
+// SubnetIPv6 returns the IPv6 address pool in CIDR notation of the given server for the replica
+// with the given ordinal. Pools are consecutive /64 networks within the configured range.
+func (c Config) SubnetIPv6(server ServerConfig, ordinal int) (string, error) {
+	_, network, err := net.ParseCIDR(c.IPv6.Pool)
+	if err != nil || network.IP.To4() != nil {
+		return "", fmt.Errorf("invalid IPv6 pool %q", c.IPv6.Pool)
+	}
+	bits, _ := network.Mask.Size()
+	index := uint64(server.PoolIndex + ordinal)
+	if bits > 64 || (bits > 0 && index >= 1<<uint(64-bits)) {
+		return "", fmt.Errorf("IPv6 pool %q is too small", c.IPv6.Pool)
+	}
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, network.IP)
+	binary.BigEndian.PutUint64(ip[:8], binary.BigEndian.Uint64(ip[:8])|index)
+	return fmt.Sprintf("%s/64", ip), nil
+}
+
+// getOrdinal returns the ordinal of the pod running the supervisor. Pods of a stateful set are
+// named after the set, suffixed by their ordinal.
+func getOrdinal() (int, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get hostname: %s", err)
+	}
+	ordinal, err := strconv.Atoi(hostname[strings.LastIndex(hostname, "-")+1:])
+	if err != nil {
+		return 0, fmt.Errorf("failed to derive ordinal from hostname %q: %s", hostname, err)
+	}
+	return ordinal, nil
+}
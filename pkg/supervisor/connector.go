@@ -0,0 +1,253 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ConnectorStatePath is the path of the endpoint reporting the state of a connector's tunnel.
+	ConnectorStatePath = "/state"
+	// ConnectorStateConnected is the state of a connector whose tunnel is established.
+	ConnectorStateConnected = "CONNECTED"
+	// DefaultManagementPort is the local port of the management interface of a connector whose
+	// configuration is passed on the command line.
+	DefaultManagementPort = 7505
+
+	forwardDialTimeout = 10 * time.Second
+)
+
+// ConnectorConfig describes the configuration of the supervisor running an OpenVPN client which
+// connects the cluster to a remote VPN.
+type ConnectorConfig struct {
+	// The path of the profile to connect with.
+	Profile string `json:"profile"`
+	// The local port of OpenVPN's management interface.
+	ManagementPort int `json:"managementPort"`
+	// The port at which the health endpoints and the state of the tunnel are exposed.
+	HealthPort int `json:"healthPort"`
+	// The local ports whose connections are forwarded to hosts in the remote networks.
+	Forwards []ForwardConfig `json:"forwards,omitempty"`
+}
+
+// ForwardConfig describes a local port whose connections are forwarded through the tunnel.
+type ForwardConfig struct {
+	Port   int    `json:"port"`
+	Target string `json:"target"`
+}
+
+// ConnectionState describes the state of the tunnel of a connector.
+type ConnectionState struct {
+	// The state reported by OpenVPN, e.g. `CONNECTED` or `RECONNECTING`.
+	State string `json:"state"`
+	// The address of the connector within the remote VPN.
+	TunnelIP string `json:"tunnelIP,omitempty"`
+	// The address of the server that the connector is connected to.
+	RemoteIP string `json:"remoteIP,omitempty"`
+}
+
+// LoadConnectorConfig reads the connector configuration from the file at the given path.
+func LoadConnectorConfig(path string) (ConnectorConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ConnectorConfig{}, fmt.Errorf("failed to read config: %s", err)
+	}
+	config := ConnectorConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ConnectorConfig{}, fmt.Errorf("failed to parse config: %s", err)
+	}
+	return config, nil
+}
+
+// Encode returns the JSON representation of the configuration.
+func (c ConnectorConfig) Encode() (string, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %s", err)
+	}
+	return string(data), nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Connector runs an OpenVPN client. It forwards connections to its local ports through the tunnel
+// and exposes the state of the tunnel.
+type Connector struct {
+	config  ConnectorConfig
+	process *process
+	logger  *zap.Logger
+}
+
+// NewConnector initializes a new connector for the given configuration.
+func NewConnector(config ConnectorConfig, logger *zap.Logger) *Connector {
+	return &Connector{config: config, logger: logger}
+}
+
+// Run starts the client and runs it until the given context is done. An error is returned if the
+// client cannot be started or exits by itself.
+func (c *Connector) Run(ctx context.Context) error {
+	// First, we start OpenVPN. Redirecting all traffic is refused as the pod would otherwise
+	// become unreachable from within the cluster.
+	if err := setupTun(c.logger); err != nil {
+		return err
+	}
+	args := []string{
+		"--config", c.config.Profile,
+		"--suppress-timestamps",
+		"--management", "127.0.0.1", strconv.Itoa(c.config.ManagementPort),
+		"--pull-filter", "ignore", "redirect-gateway",
+	}
+	logger := c.logger.Named("openvpn")
+	p, err := runOpenVPN("connector", args, logger)
+	if err != nil {
+		return fmt.Errorf("failed to start client: %s", err)
+	}
+	c.process = p
+	logger.Info("started client", zap.Int("pid", p.cmd.Process.Pid))
+
+	// Then, we expose its state and forward connections
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.serveHealth(runCtx)
+	for _, forward := range c.config.Forwards {
+		go c.forward(runCtx, forward)
+	}
+
+	// Eventually, we wait until we are asked to stop or the client exits
+	select {
+	case <-ctx.Done():
+		c.logger.Info("received termination signal, stopping client")
+		stopProcesses([]*process{p}, c.logger)
+		return nil
+	case <-p.done:
+		if p.err != nil {
+			return fmt.Errorf("client exited: %s", p.err)
+		}
+		return fmt.Errorf("client exited")
+	}
+}
+
+// serveHealth exposes the health endpoints of the connector until the given context is done.
+// `/healthz` reports whether the client is running, `/readyz` whether its tunnel is established
+// and `/state` returns the state of the tunnel.
+func (c *Connector) serveHealth(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-c.process.done:
+			http.Error(w, "client exited", http.StatusServiceUnavailable)
+		default:
+			fmt.Fprintln(w, "ok")
+		}
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		state, err := getConnectionState(c.config.ManagementPort)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if state.State != ConnectorStateConnected {
+			message := fmt.Sprintf("tunnel not established: %s", state.State)
+			http.Error(w, message, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc(ConnectorStatePath, func(w http.ResponseWriter, r *http.Request) {
+		state, err := getConnectionState(c.config.ManagementPort)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(state); err != nil {
+			c.logger.Warn("failed to write state", zap.Error(err))
+		}
+	})
+	serveHTTP(ctx, c.config.HealthPort, mux, c.logger)
+}
+
+// forward accepts connections at the given local port and forwards them to the target until the
+// given context is done.
+func (c *Connector) forward(ctx context.Context, forward ForwardConfig) {
+	logger := c.logger.With(zap.Int("port", forward.Port), zap.String("target", forward.Target))
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", forward.Port))
+	if err != nil {
+		logger.Error("failed to listen for forwarded connections", zap.Error(err))
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+	logger.Info("forwarding connections")
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			logger.Warn("failed to accept connection", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		go proxy(conn, forward.Target, logger)
+	}
+}
+
+// proxy copies data between the given connection and a new connection to the target in both
+// directions until both of them are closed.
+func proxy(conn net.Conn, target string, logger *zap.Logger) {
+	defer conn.Close()
+	upstream, err := net.DialTimeout("tcp", target, forwardDialTimeout)
+	if err != nil {
+		logger.Warn("failed to connect to target", zap.Error(err))
+		return
+	}
+	defer upstream.Close()
+
+	done := make(chan struct{}, 2)
+	copyAndClose := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		if tcp, ok := dst.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		done <- struct{}{}
+	}
+	go copyAndClose(upstream, conn)
+	go copyAndClose(conn, upstream)
+	<-done
+	<-done
+}
+
+// getConnectionState queries the state of the tunnel from the management interface at the given
+// port. OpenVPN reports it as `<time>,<state>,<description>,<local ip>,<remote ip>,...`.
+func getConnectionState(port int) (ConnectionState, error) {
+	response, err := managementCommand(port, "state")
+	if err != nil {
+		return ConnectionState{}, err
+	}
+	fields := strings.Split(response, ",")
+	if len(fields) < 2 {
+		return ConnectionState{}, fmt.Errorf("unexpected state %q", response)
+	}
+	state := ConnectionState{State: fields[1]}
+	if len(fields) >= 5 {
+		state.TunnelIP = fields[3]
+		state.RemoteIP = fields[4]
+	}
+	return state, nil
+}
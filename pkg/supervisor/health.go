@@ -0,0 +1,59 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// serveHealth exposes the health endpoints of the supervisor until the given context is done.
+// `/healthz` reports whether all processes are running while `/readyz` reports whether all of
+// them accept connections, i.e. whether their management interfaces are available.
+func (s *Supervisor) serveHealth(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		for _, p := range s.processes {
+			select {
+			case <-p.done:
+				message := fmt.Sprintf("%s server exited", p.name)
+				http.Error(w, message, http.StatusServiceUnavailable)
+				return
+			default:
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		for _, server := range s.config.Servers {
+			if err := pingManagement(server.ManagementPort); err != nil {
+				http.Error(
+					w, fmt.Sprintf("%s server not ready: %s", server.Name, err),
+					http.StatusServiceUnavailable,
+				)
+				return
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	serveHTTP(ctx, s.config.HealthPort, mux, s.logger)
+}
+
+// serveHTTP serves the given handler at the given port until the context is done.
+func serveHTTP(ctx context.Context, port int, handler http.Handler, logger *zap.Logger) {
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: handler}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("failed to shut down health endpoints", zap.Error(err))
+		}
+	}()
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("failed to serve health endpoints", zap.Error(err))
+	}
+}
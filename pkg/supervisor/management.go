@@ -0,0 +1,66 @@
+package supervisor
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const managementTimeout = 2 * time.Second
+
+// managementCommand connects to the management interface listening at the given local port and
+// runs the given command. It returns the first line of the response.
+func managementCommand(port int, command string) (string, error) {
+	conn, err := net.DialTimeout(
+		"tcp", fmt.Sprintf("127.0.0.1:%d", port), managementTimeout,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to management interface: %s", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(managementTimeout)); err != nil {
+		return "", fmt.Errorf("failed to set deadline: %s", err)
+	}
+
+	// First, the interface greets us with an informational line...
+	reader := bufio.NewReader(conn)
+	greeting, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read from management interface: %s", err)
+	}
+	if !strings.HasPrefix(greeting, ">INFO:") {
+		return "", fmt.Errorf("unexpected greeting from management interface: %q", greeting)
+	}
+	if command == "" {
+		_, err := conn.Write([]byte("exit\n"))
+		return "", err
+	}
+
+	// ... then, we can issue the command and read its response
+	if _, err := fmt.Fprintf(conn, "%s\nexit\n", command); err != nil {
+		return "", fmt.Errorf("failed to write to management interface: %s", err)
+	}
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read from management interface: %s", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// pingManagement checks whether the management interface at the given port is available.
+func pingManagement(port int) error {
+	_, err := managementCommand(port, "")
+	return err
+}
+
+// killClient disconnects the client with the given common name from the process whose management
+// interface listens at the given port. It returns whether a client was disconnected.
+func killClient(port int, commonName string) (bool, error) {
+	response, err := managementCommand(port, fmt.Sprintf("kill %s", commonName))
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(response, "SUCCESS:"), nil
+}
@@ -0,0 +1,268 @@
+package supervisor
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+const (
+	tunDevice = "/dev/net/tun"
+	// The device number of the tun device, i.e. major 10 and minor 200.
+	tunDeviceNumber = 10<<8 | 200
+
+	// The name of the nftables table holding all rules of the supervisor.
+	nftablesTable = "meerkat"
+
+	interfaceAuto  = "auto"
+	ipv6ModeRouted = "Routed"
+)
+
+// setupTun creates the tun device if it does not exist yet.
+func setupTun(logger *zap.Logger) error {
+	if info, err := os.Stat(tunDevice); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		logger.Debug("tun device exists already")
+		return nil
+	}
+	if err := os.MkdirAll("/dev/net", 0755); err != nil {
+		return fmt.Errorf("failed to create device directory: %s", err)
+	}
+	if err := syscall.Mknod(tunDevice, syscall.S_IFCHR|0600, tunDeviceNumber); err != nil {
+		return fmt.Errorf("failed to create tun device: %s", err)
+	}
+	logger.Info("created tun device")
+	return nil
+}
+
+// natRule translates the source address of traffic from the given network leaving through the
+// given interface. If no address is set, traffic is masqueraded. Exempting rules match traffic to
+// the given destination instead and prevent it from being translated by any other rule.
+type natRule struct {
+	source      string
+	destination string
+	ipv6        bool
+	iface       string
+	toAddress   string
+	exempt      bool
+}
+
+// setupNAT translates the source address of the traffic of all address pools of the replica with
+// the given ordinal as well as the traffic of additional routes and gateway subnets. Traffic to
+// gateway subnets is exempted. IPv6 traffic is only translated if IPv6 is not routed. Setup is
+// idempotent such that restarts of the supervisor within the same pod are safe.
+func setupNAT(config Config, ordinal int, logger *zap.Logger) error {
+	// First, we determine the interface that traffic leaves through...
+	iface := config.Egress.Interface
+	if iface == interfaceAuto {
+		detected, err := getDefaultRouteInterface()
+		if err != nil {
+			return err
+		}
+		logger.Info("detected egress interface", zap.String("interface", detected))
+		iface = detected
+	}
+
+	// ... and the networks whose traffic needs to be translated
+	translateIPv6 := config.IPv6 != nil && config.IPv6.Mode != ipv6ModeRouted
+	mask, _ := net.IPMask(net.ParseIP(config.SubnetMask).To4()).Size()
+	sources := []string{}
+	for _, server := range config.Servers {
+		sources = append(sources, fmt.Sprintf("%s/%d", config.Subnet(server, ordinal), mask))
+		if translateIPv6 {
+			subnet, err := config.SubnetIPv6(server, ordinal)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, subnet)
+		}
+	}
+	sources = append(sources, config.Routes...)
+	sources = append(sources, config.GatewaySubnets...)
+
+	rules := []natRule{}
+	for _, destination := range config.GatewaySubnets {
+		_, network, err := net.ParseCIDR(destination)
+		if err != nil {
+			return fmt.Errorf("failed to parse network %q: %s", destination, err)
+		}
+		if network.IP.To4() == nil && !translateIPv6 {
+			continue
+		}
+		rules = append(rules, natRule{
+			destination: network.String(),
+			ipv6:        network.IP.To4() == nil,
+			iface:       iface,
+			exempt:      true,
+		})
+	}
+	for _, source := range sources {
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return fmt.Errorf("failed to parse network %q: %s", source, err)
+		}
+		rule := natRule{source: network.String(), iface: iface}
+		if network.IP.To4() == nil {
+			if !translateIPv6 {
+				continue
+			}
+			rule.ipv6 = true
+		} else {
+			rule.toAddress = config.Egress.SNATAddress
+		}
+		rules = append(rules, rule)
+	}
+
+	// Eventually, we can apply the rules using the configured backend
+	switch config.Egress.Backend {
+	case "nftables":
+		return applyNftablesRules(rules, logger)
+	case "iptables-nft":
+		return applyIptablesRules("nft", rules, logger)
+	default:
+		return applyIptablesRules("legacy", rules, logger)
+	}
+}
+
+// setupForwarding enables forwarding of IPv6 traffic within the pod. Forwarding might not be
+// permitted to be changed by the container, so failures are only reported.
+func setupForwarding(logger *zap.Logger) {
+	path := "/proc/sys/net/ipv6/conf/all/forwarding"
+	if err := ioutil.WriteFile(path, []byte("1"), 0644); err != nil {
+		logger.Warn("failed to enable IPv6 forwarding, it must be enabled via the pod's sysctls",
+			zap.Error(err),
+		)
+		return
+	}
+	logger.Info("enabled IPv6 forwarding")
+}
+
+func applyIptablesRules(variant string, rules []natRule, logger *zap.Logger) error {
+	for _, rule := range rules {
+		binary := getIptablesBinary("iptables", variant, logger)
+		if rule.ipv6 {
+			binary = getIptablesBinary("ip6tables", variant, logger)
+		}
+		args := []string{"POSTROUTING"}
+		switch {
+		case rule.exempt:
+			args = append(args, "-d", rule.destination, "-o", rule.iface, "-j", "RETURN")
+		case rule.toAddress != "":
+			args = append(args, "-s", rule.source, "-o", rule.iface,
+				"-j", "SNAT", "--to-source", rule.toAddress,
+			)
+		default:
+			args = append(args, "-s", rule.source, "-o", rule.iface, "-j", "MASQUERADE")
+		}
+
+		// First, we check whether the rule exists...
+		check := append([]string{"-t", "nat", "-C"}, args...)
+		if err := exec.Command(binary, check...).Run(); err == nil {
+			logger.Debug("iptables rule exists already",
+				zap.String("binary", binary), zap.Strings("rule", args),
+			)
+			continue
+		}
+
+		// ... and add it otherwise. Exemptions must precede all other rules.
+		add := append([]string{"-t", "nat", "-A"}, args...)
+		if rule.exempt {
+			add = append([]string{"-t", "nat", "-I", args[0], "1"}, args[1:]...)
+		}
+		if output, err := exec.Command(binary, add...).CombinedOutput(); err != nil {
+			return fmt.Errorf(
+				"failed to add %s rule '%s': %s: %s",
+				binary, strings.Join(args, " "), err, strings.TrimSpace(string(output)),
+			)
+		}
+		logger.Info("added iptables rule", zap.String("binary", binary), zap.Strings("rule", args))
+	}
+	return nil
+}
+
+// getIptablesBinary returns the binary of the given variant. Images may only ship a single variant
+// under the plain name which is used as a fallback.
+func getIptablesBinary(name, variant string, logger *zap.Logger) string {
+	binary := fmt.Sprintf("%s-%s", name, variant)
+	if _, err := exec.LookPath(binary); err != nil {
+		logger.Debug("iptables variant not found, falling back to plain name",
+			zap.String("binary", binary),
+		)
+		return name
+	}
+	return binary
+}
+
+func applyNftablesRules(rules []natRule, logger *zap.Logger) error {
+	// The tables are recreated entirely within a single transaction. Declaring them before
+	// flushing makes sure that the flush succeeds if they do not exist yet.
+	script := strings.Builder{}
+	for _, family := range []string{"ip", "ip6"} {
+		fmt.Fprintf(&script, "table %s %s {}\n", family, nftablesTable)
+		fmt.Fprintf(&script, "flush table %s %s\n", family, nftablesTable)
+		fmt.Fprintf(&script, "table %s %s {\n", family, nftablesTable)
+		script.WriteString("\tchain postrouting {\n")
+		script.WriteString("\t\ttype nat hook postrouting priority 100; policy accept;\n")
+		for _, rule := range rules {
+			if rule.ipv6 != (family == "ip6") {
+				continue
+			}
+			if rule.exempt {
+				fmt.Fprintf(
+					&script, "\t\t%s daddr %s oifname %q return\n",
+					family, rule.destination, rule.iface,
+				)
+				continue
+			}
+			action := "masquerade"
+			if rule.toAddress != "" {
+				action = fmt.Sprintf("snat to %s", rule.toAddress)
+			}
+			fmt.Fprintf(
+				&script, "\t\t%s saddr %s oifname %q %s\n",
+				family, rule.source, rule.iface, action,
+			)
+		}
+		script.WriteString("\t}\n}\n")
+	}
+
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script.String())
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf(
+			"failed to apply nftables rules: %s: %s", err, strings.TrimSpace(string(output)),
+		)
+	}
+	logger.Info("applied nftables rules",
+		zap.String("table", nftablesTable), zap.Int("rules", len(rules)),
+	)
+	return nil
+}
+
+// getDefaultRouteInterface returns the interface of the IPv4 default route.
+func getDefaultRouteInterface() (string, error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return "", fmt.Errorf("failed to read routes: %s", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Columns are the interface, the destination and the gateway
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[1] == "00000000" {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read routes: %s", err)
+	}
+	return "", fmt.Errorf("failed to find default route")
+}
@@ -0,0 +1,172 @@
+package supervisor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// process is a single OpenVPN process whose output is logged line by line.
+type process struct {
+	name   string
+	cmd    *exec.Cmd
+	done   chan struct{}
+	err    error
+	logger *zap.Logger
+}
+
+// startProcess starts OpenVPN for the given server. The address pools are passed on the command
+// line as they depend on the ordinal of the pod.
+func startProcess(
+	config Config, server ServerConfig, ordinal int, logger *zap.Logger,
+) (*process, error) {
+	subnet := config.Subnet(server, ordinal)
+	args := []string{
+		"--config", server.Config,
+		"--suppress-timestamps",
+		"--server", subnet, config.SubnetMask,
+		"--push", fmt.Sprintf("route %s %s", subnet, config.SubnetMask),
+	}
+	fields := []zap.Field{zap.String("subnet", subnet)}
+	if config.IPv6 != nil {
+		subnetIPv6, err := config.SubnetIPv6(server, ordinal)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args,
+			"--server-ipv6", subnetIPv6,
+			"--push", fmt.Sprintf("route-ipv6 %s", subnetIPv6),
+		)
+		fields = append(fields, zap.String("subnetIPv6", subnetIPv6))
+	}
+	p, err := runOpenVPN(server.Name, args, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start %s server: %s", server.Name, err)
+	}
+	logger.Info("started server", append(fields, zap.Int("pid", p.cmd.Process.Pid))...)
+	return p, nil
+}
+
+// runOpenVPN starts OpenVPN with the given arguments and forwards its output to the logger.
+func runOpenVPN(name string, args []string, logger *zap.Logger) (*process, error) {
+	cmd := exec.Command("openvpn", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout: %s", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stderr: %s", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{
+		name:   name,
+		cmd:    cmd,
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+
+	// The output must be consumed entirely before waiting for the process to exit
+	outputs := make(chan struct{}, 2)
+	go func() { p.forwardLogs(stdout); outputs <- struct{}{} }()
+	go func() { p.forwardLogs(stderr); outputs <- struct{}{} }()
+	go func() {
+		<-outputs
+		<-outputs
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// stopProcesses terminates the given processes and waits for them to exit. Processes which do not
+// exit in time are killed.
+func stopProcesses(processes []*process, logger *zap.Logger) {
+	for _, p := range processes {
+		p.signal(syscall.SIGTERM)
+	}
+	timeout := time.After(shutdownTimeout)
+	for _, p := range processes {
+		select {
+		case <-p.done:
+		case <-timeout:
+			logger.Warn("process did not stop in time, killing it", zap.String("name", p.name))
+			p.signal(syscall.SIGKILL)
+			<-p.done
+		}
+	}
+}
+
+// signal sends the given signal to the process unless it exited already.
+func (p *process) signal(sig os.Signal) {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	if err := p.cmd.Process.Signal(sig); err != nil {
+		p.logger.Warn("failed to signal server", zap.Stringer("signal", sig), zap.Error(err))
+	}
+}
+
+func (p *process) forwardLogs(reader io.Reader) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		level, message, fields := parseLogLine(scanner.Text())
+		if message == "" {
+			continue
+		}
+		if ce := p.logger.Check(level, message); ce != nil {
+			ce.Write(fields...)
+		}
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Lines that concern a single client are prefixed by the client's address and, once the client is
+// authenticated, its common name.
+var clientLinePattern = regexp.MustCompile(
+	`^(?:([^\s/]+)/)?((?:\[AF_INET6?\])?[0-9a-fA-F.:\[\]]+:\d+) (.*)$`,
+)
+
+// parseLogLine turns a line of OpenVPN output into a log entry. The level is derived from the
+// message's prefix and client information is extracted into separate fields.
+func parseLogLine(line string) (zapcore.Level, string, []zap.Field) {
+	message := strings.TrimSpace(line)
+	fields := []zap.Field{}
+	if match := clientLinePattern.FindStringSubmatch(message); match != nil {
+		if match[1] != "" {
+			fields = append(fields, zap.String("client", match[1]))
+		}
+		fields = append(fields, zap.String("address", match[2]))
+		message = match[3]
+	}
+
+	level := zapcore.InfoLevel
+	switch {
+	case strings.HasPrefix(message, "WARNING"):
+		level = zapcore.WarnLevel
+	case strings.HasPrefix(message, "ERROR"),
+		strings.HasPrefix(message, "FATAL"),
+		strings.HasPrefix(message, "Options error"),
+		strings.HasPrefix(message, "Exiting due to fatal error"):
+		level = zapcore.ErrorLevel
+	case strings.HasPrefix(message, "TLS Error"), strings.HasPrefix(message, "VERIFY ERROR"),
+		strings.HasPrefix(message, "AUTH_FAILED"):
+		level = zapcore.WarnLevel
+	}
+	return level, message, fields
+}
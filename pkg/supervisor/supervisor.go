@@ -0,0 +1,274 @@
+package supervisor
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+const (
+	shutdownTimeout    = 20 * time.Second
+	suspensionPeriod   = 30 * time.Second
+	clientConfigPeriod = 30 * time.Second
+	reloadCheckPeriod  = time.Minute
+)
+
+// Supervisor runs the OpenVPN processes of a server. It sets up the network of the pod, forwards
+// the output of all processes as structured logs and stops all processes as soon as one of them
+// exits.
+type Supervisor struct {
+	config    Config
+	processes []*process
+	logger    *zap.Logger
+}
+
+// New initializes a new supervisor for the given configuration.
+func New(config Config, logger *zap.Logger) *Supervisor {
+	return &Supervisor{config: config, logger: logger}
+}
+
+// Run sets up the network and runs all processes until the given context is done. Processes are
+// then terminated gracefully. An error is returned if setup fails or any process exits by itself.
+func (s *Supervisor) Run(ctx context.Context) error {
+	// First, we set up the network of the pod
+	ordinal, err := getOrdinal()
+	if err != nil {
+		return err
+	}
+	if err := setupTun(s.logger); err != nil {
+		return err
+	}
+	if err := setupNAT(s.config, ordinal, s.logger); err != nil {
+		return err
+	}
+	if s.config.IPv6 != nil {
+		setupForwarding(s.logger)
+	}
+	if s.config.ClientConfigs != "" {
+		_, err := writeClientConfigs(s.config.ClientConfigs, s.config.ClientConfigDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Then, we can start all processes...
+	for _, server := range s.config.Servers {
+		logger := s.logger.Named("openvpn").With(zap.String("server", server.Name))
+		p, err := startProcess(s.config, server, ordinal, logger)
+		if err != nil {
+			s.stop()
+			return err
+		}
+		s.processes = append(s.processes, p)
+	}
+
+	// ... along with the routines watching them
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.serveHealth(runCtx)
+	go s.disconnectSuspendedClients(runCtx)
+	if s.config.ClientConfigs != "" {
+		go s.syncClientConfigs(runCtx)
+	}
+	if len(s.config.ReloadFiles) > 0 {
+		go s.reloadOnChange(runCtx)
+	}
+
+	// Eventually, we wait until we are asked to stop or any of the processes exits
+	exited := make(chan *process, len(s.processes))
+	for _, p := range s.processes {
+		go func(p *process) {
+			<-p.done
+			exited <- p
+		}(p)
+	}
+	select {
+	case <-ctx.Done():
+		s.logger.Info("received termination signal, stopping servers")
+		s.stop()
+		return nil
+	case p := <-exited:
+		s.logger.Error("server exited, stopping remaining servers", zap.String("server", p.name))
+		s.stop()
+		if p.err != nil {
+			return fmt.Errorf("%s server exited: %s", p.name, p.err)
+		}
+		return fmt.Errorf("%s server exited", p.name)
+	}
+}
+
+// stop terminates all processes and waits for them to exit.
+func (s *Supervisor) stop() {
+	stopProcesses(s.processes, s.logger)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// disconnectSuspendedClients periodically disconnects all suspended clients which are still
+// connected. New connections of suspended clients are rejected by the servers themselves.
+func (s *Supervisor) disconnectSuspendedClients(ctx context.Context) {
+	ticker := time.NewTicker(suspensionPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		clients, err := readLines(s.config.SuspendedClients)
+		if err != nil {
+			s.logger.Warn("failed to read suspended clients", zap.Error(err))
+			continue
+		}
+		for _, client := range clients {
+			for _, server := range s.config.Servers {
+				killed, err := killClient(server.ManagementPort, client)
+				if err != nil {
+					s.logger.Warn("failed to disconnect suspended client",
+						zap.String("server", server.Name), zap.String("client", client),
+						zap.Error(err),
+					)
+				} else if killed {
+					s.logger.Info("disconnected suspended client",
+						zap.String("server", server.Name), zap.String("client", client),
+					)
+				}
+			}
+		}
+	}
+}
+
+// syncClientConfigs periodically updates the client config directory. Servers read the config of
+// a client whenever it connects, so changes do not require a reload.
+func (s *Supervisor) syncClientConfigs(ctx context.Context) {
+	ticker := time.NewTicker(clientConfigPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		changed, err := writeClientConfigs(s.config.ClientConfigs, s.config.ClientConfigDir)
+		if err != nil {
+			s.logger.Warn("failed to update client configs", zap.Error(err))
+		} else if changed > 0 {
+			s.logger.Info("updated client configs", zap.Int("changed", changed))
+		}
+	}
+}
+
+// reloadOnChange reloads all processes whenever the contents of any of the reload files change.
+// As files mounted from configmaps and secrets are replaced by swapping symlinks, the directories
+// containing the files are watched. Changes are additionally checked for periodically in case
+// any event is missed.
+func (s *Supervisor) reloadOnChange(ctx context.Context) {
+	current, err := checksum(s.config.ReloadFiles)
+	if err != nil {
+		s.logger.Warn("failed to compute checksum of reload files", zap.Error(err))
+	}
+
+	var events chan fsnotify.Event
+	var errors chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.logger.Error("failed to initialize file watcher", zap.Error(err))
+	} else {
+		defer watcher.Close()
+		events, errors = watcher.Events, watcher.Errors
+		for _, dir := range directories(s.config.ReloadFiles) {
+			if err := watcher.Add(dir); err != nil {
+				s.logger.Warn("failed to watch directory", zap.String("dir", dir), zap.Error(err))
+			}
+		}
+	}
+
+	ticker := time.NewTicker(reloadCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		case err := <-errors:
+			s.logger.Warn("received error from file watcher", zap.Error(err))
+			continue
+		case <-ticker.C:
+		}
+
+		updated, err := checksum(s.config.ReloadFiles)
+		if err != nil {
+			// Files may be missing temporarily while they are being replaced
+			s.logger.Debug("failed to compute checksum of reload files", zap.Error(err))
+			continue
+		}
+		if updated == current {
+			continue
+		}
+		current = updated
+		s.logger.Info("reload files changed, reloading servers")
+		for _, p := range s.processes {
+			p.signal(syscall.SIGHUP)
+		}
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			result = append(result, line)
+		}
+	}
+	return result, scanner.Err()
+}
+
+func checksum(paths []string) (string, error) {
+	hash := sha256.New()
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		hash.Write([]byte(path))
+		hash.Write([]byte{0})
+		hash.Write(data)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func directories(paths []string) []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if !seen[dir] {
+			result = append(result, dir)
+			seen[dir] = true
+		}
+	}
+	return result
+}
@@ -0,0 +1,425 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"go.uber.org/zap"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// PathInjector is the path at which the injector webhook is served.
+const PathInjector = "/mutate-inject"
+
+const (
+	// SidecarName is the name of the injected VPN sidecar container.
+	SidecarName = "meerkat-vpn"
+	// SidecarHealthPort is the port at which the sidecar exposes its health endpoints. It is
+	// chosen such that it is unlikely to collide with the ports of the pod's containers.
+	SidecarHealthPort = 15490
+
+	sidecarVolumeName   = "meerkat-vpn-profile"
+	sidecarMountPath    = "/var/run/meerkat/profile"
+	sidecarProfileFile  = "profile.ovpn"
+	sidecarStartTimeout = 120
+	supervisorBinary    = "/usr/local/bin/meerkat-supervisor"
+)
+
+// nativeSidecarVersion is the first Kubernetes version which runs native sidecars by default.
+var nativeSidecarVersion = version.MustParseGeneric("1.29.0")
+
+// InjectorWebhook injects a sidecar running an OpenVPN client into pods which request it via the
+// `meerkat.borchero.com/inject` annotation. The sidecar connects with the profile of the
+// referenced OvpnClient which is created if the pod also names the server to create it for and
+// either the pod's creator or its service account may create clients.
+type InjectorWebhook struct {
+	client         client.Client
+	recorder       record.EventRecorder
+	image          string
+	nativeSidecars bool
+	logger         *zap.Logger
+}
+
+// SetupInjectorWebhook initializes a new injector webhook running sidecars with the given image
+// and registers it with the webhook server of the given manager. Sidecars are injected as native
+// sidecar containers if the cluster supports them.
+func SetupInjectorWebhook(mgr ctrl.Manager, image string, logger *zap.Logger) {
+	nativeSidecars, err := supportsNativeSidecars(mgr.GetConfig())
+	if err != nil {
+		logger.Warn("failed to detect support for native sidecars", zap.Error(err))
+	}
+	logger.Info("detected sidecar support", zap.Bool("native", nativeSidecars))
+	mgr.GetWebhookServer().Register(PathInjector, &webhook.Admission{
+		Handler: &InjectorWebhook{
+			client:         mgr.GetClient(),
+			recorder:       mgr.GetEventRecorderFor("injector-webhook"),
+			image:          image,
+			nativeSidecars: nativeSidecars,
+			logger:         logger,
+		},
+	})
+}
+
+// supportsNativeSidecars checks whether the cluster runs Kubernetes 1.29 or later where init
+// containers which restart always are run as sidecars alongside the pod's containers. Older
+// clusters drop their restart policy, leaving pods stuck initializing.
+func supportsNativeSidecars(config *rest.Config) (bool, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to create discovery client: %s", err)
+	}
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("failed to get server version: %s", err)
+	}
+	current, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid server version %q: %s", info.GitVersion, err)
+	}
+	return current.AtLeast(nativeSidecarVersion), nil
+}
+
+// Handle handles the given admission request.
+func (w *InjectorWebhook) Handle(
+	ctx context.Context, req admission.Request,
+) admission.Response {
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// First, we check whether a sidecar is requested and not injected yet
+	clientName := pod.Annotations[api.AnnotationKeyInject]
+	if clientName == "" {
+		return admission.Allowed("no sidecar requested")
+	}
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		if container.Name == SidecarName {
+			return admission.Allowed("sidecar already injected")
+		}
+	}
+
+	// Then, we find the profile that the sidecar connects with, creating the client if required
+	ovpnClient, reason, err := w.getClient(ctx, req, pod, clientName)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+
+	// Eventually, we inject the sidecar. The pod is patched in its raw form such that fields
+	// unknown to the API types of the operator are preserved.
+	mutated, err := injectSidecar(req.Object.Raw, w.image,
+		ovpnClient.ObjectRefCertificateSecret().Name, ovpnClient.ProfileKey(), w.nativeSidecars)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	w.logger.Debug("injected sidecar",
+		zap.String("namespace", req.Namespace),
+		zap.String("pod", pod.Name+pod.GenerateName),
+		zap.String("client", clientName),
+	)
+	return admission.PatchResponseFromRaw(req.Object.Raw, mutated)
+}
+
+// getClient returns the OvpnClient with the given name in the request's namespace. If it does not
+// exist, it is created for the server named by the pod's annotation on behalf of the user
+// creating the pod or, if the user may not create clients, on behalf of the pod's service account.
+// The latter allows pods created by the controllers of deployments and jobs to request clients.
+// The identity on whose behalf the client is created is recorded as the requester of its
+// certificate. If no server is named or neither identity may create clients, the reason is
+// returned instead. For dry runs, the client is not created.
+func (w *InjectorWebhook) getClient(
+	ctx context.Context, req admission.Request, pod *corev1.Pod, name string,
+) (*api.OvpnClient, string, error) {
+	ovpnClient := &api.OvpnClient{}
+	key := client.ObjectKey{Namespace: req.Namespace, Name: name}
+	err := w.client.Get(ctx, key, ovpnClient)
+	if err == nil {
+		return ovpnClient, "", nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, "", fmt.Errorf("failed to get client: %s", err)
+	}
+
+	serverName := pod.Annotations[api.AnnotationKeyInjectServer]
+	if serverName == "" {
+		return nil, fmt.Sprintf(
+			"OvpnClient %q does not exist and %q is not set",
+			name, api.AnnotationKeyInjectServer,
+		), nil
+	}
+
+	// The operator must not create clients on behalf of identities which cannot create them
+	// themselves
+	requester, err := w.getRequester(ctx, req, pod)
+	if err != nil {
+		return nil, "", err
+	}
+	if requester == "" {
+		reason := fmt.Sprintf(
+			"OvpnClient %q does not exist and neither %q nor service account %q may create "+
+				"clients in namespace %q",
+			name, req.UserInfo.Username, serviceAccountName(pod), req.Namespace,
+		)
+		if owner := metav1.GetControllerOf(pod); owner != nil && !isDryRun(req) {
+			w.recorder.Event(&corev1.ObjectReference{
+				APIVersion: owner.APIVersion,
+				Kind:       owner.Kind,
+				Namespace:  req.Namespace,
+				Name:       owner.Name,
+				UID:        owner.UID,
+			}, corev1.EventTypeWarning, "ClientCreationDenied", reason)
+		}
+		return nil, reason, nil
+	}
+
+	ovpnClient = &api.OvpnClient{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: req.Namespace,
+			Annotations: map[string]string{
+				api.AnnotationKeyRequestedBy: requester,
+			},
+		},
+		Spec: api.OvpnClientSpec{
+			ServerName: serverName,
+			CommonName: fmt.Sprintf("%s.%s", name, req.Namespace),
+		},
+	}
+	if isDryRun(req) {
+		return ovpnClient, "", nil
+	}
+	if err := w.client.Create(ctx, ovpnClient); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, "", fmt.Errorf("failed to create client: %s", err)
+	}
+	w.logger.Info("created client for sidecar",
+		zap.String("namespace", req.Namespace),
+		zap.String("client", name),
+		zap.String("server", serverName),
+		zap.String("requester", requester),
+	)
+	return ovpnClient, "", nil
+}
+
+// getRequester returns the identity on whose behalf a client is created for the given pod. This
+// is the user issuing the request if it may create OvpnClients in the request's namespace and the
+// pod's service account otherwise. If neither may create clients, an empty string is returned.
+func (w *InjectorWebhook) getRequester(
+	ctx context.Context, req admission.Request, pod *corev1.Pod,
+) (string, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range req.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	allowed, err := w.mayCreateClient(ctx, req.Namespace, authorizationv1.SubjectAccessReviewSpec{
+		User:   req.UserInfo.Username,
+		Groups: req.UserInfo.Groups,
+		UID:    req.UserInfo.UID,
+		Extra:  extra,
+	})
+	if err != nil || allowed {
+		return req.UserInfo.Username, err
+	}
+
+	serviceAccount := fmt.Sprintf(
+		"system:serviceaccount:%s:%s", req.Namespace, serviceAccountName(pod),
+	)
+	allowed, err = w.mayCreateClient(ctx, req.Namespace, authorizationv1.SubjectAccessReviewSpec{
+		User: serviceAccount,
+		Groups: []string{
+			"system:serviceaccounts",
+			fmt.Sprintf("system:serviceaccounts:%s", req.Namespace),
+			"system:authenticated",
+		},
+	})
+	if err != nil || !allowed {
+		return "", err
+	}
+	return serviceAccount, nil
+}
+
+// mayCreateClient checks whether the subject of the given review may create OvpnClients in the
+// given namespace.
+func (w *InjectorWebhook) mayCreateClient(
+	ctx context.Context, namespace string, spec authorizationv1.SubjectAccessReviewSpec,
+) (bool, error) {
+	spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "create",
+		Group:     api.GroupVersion.Group,
+		Version:   api.GroupVersion.Version,
+		Resource:  "ovpnclients",
+	}
+	review := &authorizationv1.SubjectAccessReview{Spec: spec}
+	if err := w.client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review access of %q: %s", spec.User, err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// serviceAccountName returns the name of the service account that the given pod runs as.
+func serviceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName == "" {
+		return "default"
+	}
+	return pod.Spec.ServiceAccountName
+}
+
+// isDryRun returns whether the given request is a dry run.
+func isDryRun(req admission.Request) bool {
+	return req.DryRun != nil && *req.DryRun
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// injectSidecar adds the VPN sidecar connecting with the profile found at the given key of the
+// given secret to the raw pod. The pod's other containers only start once the tunnel is
+// established and the pod is only ready while it is.
+//
+// If native sidecars are supported, the sidecar is the first init container of the pod and
+// restarts always. Its startup probe blocks the pod's containers and it does not keep pods of jobs
+// from completing. Otherwise, it is the first container of the pod and its post-start hook blocks
+// the other containers as the kubelet starts containers in order. Pods of jobs then do not
+// complete on their own.
+func injectSidecar(
+	raw []byte, image, secretName, secretKey string, native bool,
+) ([]byte, error) {
+	var rootUser int64 = 0
+	var secretMode int32 = 0600
+
+	sidecar := corev1.Container{
+		Name:            SidecarName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command: []string{
+			supervisorBinary,
+			"-mode", "connector",
+			"-profile", filepath.Join(sidecarMountPath, sidecarProfileFile),
+			"-health-port", strconv.Itoa(SidecarHealthPort),
+		},
+		SecurityContext: &corev1.SecurityContext{
+			RunAsUser: &rootUser,
+			Capabilities: &corev1.Capabilities{
+				Add: []corev1.Capability{corev1.Capability("NET_ADMIN")},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{{
+			Name:      sidecarVolumeName,
+			MountPath: sidecarMountPath,
+			ReadOnly:  true,
+		}},
+	}
+	if native {
+		startupProbe := getSidecarProbe("/readyz", 0)
+		startupProbe.PeriodSeconds = 1
+		startupProbe.FailureThreshold = sidecarStartTimeout
+		sidecar.StartupProbe = startupProbe
+		sidecar.ReadinessProbe = getSidecarProbe("/readyz", 0)
+		sidecar.LivenessProbe = getSidecarProbe("/healthz", 0)
+	} else {
+		waitScript := fmt.Sprintf(
+			"for i in $(seq %d); do wget -q -O /dev/null http://127.0.0.1:%d/readyz && exit 0; "+
+				"sleep 1; done; exit 1",
+			sidecarStartTimeout, SidecarHealthPort,
+		)
+		sidecar.ReadinessProbe = getSidecarProbe("/readyz", 5)
+		sidecar.LivenessProbe = getSidecarProbe("/healthz", 10)
+		sidecar.Lifecycle = &corev1.Lifecycle{
+			PostStart: &corev1.Handler{
+				Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", waitScript}},
+			},
+		}
+	}
+	volume := corev1.Volume{
+		Name: sidecarVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+				Items: []corev1.KeyToPath{{
+					Key:  secretKey,
+					Path: sidecarProfileFile,
+				}},
+				DefaultMode: &secretMode,
+			},
+		},
+	}
+
+	// The restart policy of containers is not part of the operator's API types, so it is set on
+	// the unstructured container
+	container, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&sidecar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sidecar: %s", err)
+	}
+	field := "containers"
+	if native {
+		container["restartPolicy"] = string(corev1.RestartPolicyAlways)
+		field = "initContainers"
+	}
+	unstructuredVolume, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&volume)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert sidecar volume: %s", err)
+	}
+
+	pod := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &pod); err != nil {
+		return nil, fmt.Errorf("failed to decode pod: %s", err)
+	}
+	containers, _, err := unstructured.NestedSlice(pod, "spec", field)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", field, err)
+	}
+	containers = append([]interface{}{container}, containers...)
+	if err := unstructured.SetNestedSlice(pod, containers, "spec", field); err != nil {
+		return nil, fmt.Errorf("failed to add sidecar: %s", err)
+	}
+	volumes, _, err := unstructured.NestedSlice(pod, "spec", "volumes")
+	if err != nil {
+		return nil, fmt.Errorf("invalid volumes: %s", err)
+	}
+	volumes = append(volumes, unstructuredVolume)
+	if err := unstructured.SetNestedSlice(pod, volumes, "spec", "volumes"); err != nil {
+		return nil, fmt.Errorf("failed to add sidecar volume: %s", err)
+	}
+	return json.Marshal(pod)
+}
+
+// getSidecarProbe returns a probe querying the given health endpoint of the sidecar.
+func getSidecarProbe(path string, initialDelay int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   path,
+				Port:   intstr.FromInt(SidecarHealthPort),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		InitialDelaySeconds: initialDelay,
+		TimeoutSeconds:      5,
+		PeriodSeconds:       10,
+		SuccessThreshold:    1,
+		FailureThreshold:    3,
+	}
+}
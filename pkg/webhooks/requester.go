@@ -0,0 +1,85 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	api "github.com/borchero/meerkat-operator/pkg/api/v1alpha1"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// PathRequester is the path at which the requester webhook is served.
+const PathRequester = "/mutate-requester"
+
+// RequesterWebhook records the user who requests certificates for OVPN servers and clients in an
+// annotation on the respective object.
+type RequesterWebhook struct {
+	operator string
+	logger   *zap.Logger
+}
+
+// SetupRequesterWebhook initializes a new requester webhook and registers it with the webhook
+// server of the given manager. The operator, identified by the given user name, may record the
+// requester itself when it creates objects on behalf of other users.
+func SetupRequesterWebhook(mgr ctrl.Manager, operator string, logger *zap.Logger) {
+	mgr.GetWebhookServer().Register(PathRequester, &webhook.Admission{
+		Handler: &RequesterWebhook{operator: operator, logger: logger},
+	})
+}
+
+// Handle handles the given admission request.
+func (w *RequesterWebhook) Handle(
+	ctx context.Context, req admission.Request,
+) admission.Response {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(req.Object.Raw); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// The requester is the user issuing the request. For updates, the requester only changes if
+	// the certificate's reissue is requested. Otherwise, the previous requester is kept such that
+	// it cannot be tampered with. Objects created by the operator keep the requester it recorded.
+	requester := req.UserInfo.Username
+	if req.Operation == admissionv1.Create && requester == w.operator && w.operator != "" {
+		if recorded := obj.GetAnnotations()[api.AnnotationKeyRequestedBy]; recorded != "" {
+			requester = recorded
+		}
+	}
+	if req.Operation == admissionv1.Update {
+		old := &unstructured.Unstructured{}
+		if err := old.UnmarshalJSON(req.OldObject.Raw); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		previousReissue := old.GetAnnotations()[api.AnnotationKeyReissue]
+		if previousReissue == obj.GetAnnotations()[api.AnnotationKeyReissue] {
+			requester = old.GetAnnotations()[api.AnnotationKeyRequestedBy]
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if requester == "" {
+		delete(annotations, api.AnnotationKeyRequestedBy)
+	} else {
+		annotations[api.AnnotationKeyRequestedBy] = requester
+	}
+	obj.SetAnnotations(annotations)
+
+	mutated, err := obj.MarshalJSON()
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	w.logger.Debug("recorded requester",
+		zap.String("kind", req.Kind.Kind),
+		zap.String("name", req.Name),
+		zap.String("requester", requester),
+	)
+	return admission.PatchResponseFromRaw(req.Object.Raw, mutated)
+}